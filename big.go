@@ -0,0 +1,266 @@
+package mapstructure
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+)
+
+// isBigType reports whether t is big.Int, big.Float or big.Rat, or a
+// pointer to one of them.
+func isBigType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t == bigIntType || t == bigFloatType || t == bigRatType
+}
+
+// decodeBig decodes data into a big.Int, big.Float or big.Rat. Strings and
+// json.Number are parsed directly, without going through float64. Inputs
+// which cannot be represented exactly, such as 1.5 for a big.Int, are
+// rejected.
+func (d *Decoder) decodeBig(name string, data any, val reflect.Value) error {
+	dataVal := reflect.Indirect(reflect.ValueOf(data))
+
+	var err error
+	switch z := val.Addr().Interface().(type) {
+	case *big.Int:
+		err = decodeBigInt(z, dataVal)
+	case *big.Float:
+		err = decodeBigFloat(z, dataVal)
+	case *big.Rat:
+		err = decodeBigRat(z, dataVal)
+	}
+
+	if errors.Is(err, errUnconvertibleBig) {
+		return newDecodeError(name, &UnconvertibleTypeError{
+			Expected: val,
+			Value:    data,
+		})
+	}
+	if err != nil {
+		return newDecodeError(name, &ParseError{
+			Expected: val,
+			Value:    data,
+			Err:      err,
+		})
+	}
+
+	return nil
+}
+
+var (
+	errUnconvertibleBig = errors.New("unconvertible type")
+	errNotInteger       = errors.New("value is not an integer")
+	errInexact          = errors.New("value cannot be represented exactly")
+	errNotFinite        = errors.New("value is not finite")
+)
+
+// bigRat returns v as a big.Rat if v is a number or a numeric string.
+func bigRat(v reflect.Value) (*big.Rat, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, errNotFinite
+		}
+
+		return new(big.Rat).SetFloat64(f), nil
+	case reflect.String:
+		r, ok := new(big.Rat).SetString(strings.TrimSpace(v.String()))
+		if !ok {
+			return nil, errors.New("invalid number")
+		}
+
+		return r, nil
+	}
+
+	switch x := v.Addr().Interface().(type) {
+	case *big.Int:
+		return new(big.Rat).SetInt(x), nil
+	case *big.Rat:
+		return new(big.Rat).Set(x), nil
+	case *big.Float:
+		if x.IsInf() {
+			return nil, errNotFinite
+		}
+
+		r, _ := x.Rat(nil)
+
+		return r, nil
+	}
+
+	return nil, errUnconvertibleBig
+}
+
+// addressable returns an addressable copy of v if v is not addressable.
+func addressable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanAddr() {
+		return v
+	}
+
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+
+	return c
+}
+
+func decodeBigInt(z *big.Int, v reflect.Value) error {
+	v = addressable(v)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		z.SetInt64(v.Int())
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		z.SetUint64(v.Uint())
+		return nil
+	case reflect.String:
+		// Integers are parsed as such to accept base prefixes.
+		if _, ok := z.SetString(strings.TrimSpace(v.String()), 0); ok {
+			return nil
+		}
+	}
+
+	r, err := bigRat(v)
+	if err != nil {
+		return err
+	}
+	if !r.IsInt() {
+		return errNotInteger
+	}
+
+	z.Set(r.Num())
+
+	return nil
+}
+
+func decodeBigFloat(z *big.Float, v reflect.Value) error {
+	v = addressable(v)
+
+	switch v.Kind() {
+	case reflect.String:
+		s := strings.TrimSpace(v.String())
+		if z.Prec() == 0 {
+			z.SetPrec(decimalPrec(s))
+		}
+
+		if _, ok := z.SetString(s); !ok {
+			return errors.New("invalid number")
+		}
+
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) {
+			return errNotFinite
+		}
+
+		if z.SetFloat64(f).Acc() != big.Exact {
+			return errInexact
+		}
+
+		return nil
+	}
+
+	if x, ok := v.Addr().Interface().(*big.Float); ok {
+		if z.Prec() == 0 {
+			z.SetPrec(x.Prec())
+		}
+		if z.Set(x).Acc() != big.Exact {
+			return errInexact
+		}
+
+		return nil
+	}
+
+	r, err := bigRat(v)
+	if err != nil {
+		return err
+	}
+
+	if r.IsInt() {
+		if z.Prec() == 0 {
+			z.SetPrec(maxPrec(r.Num().BitLen()))
+		}
+		if z.SetInt(r.Num()).Acc() != big.Exact {
+			return errInexact
+		}
+
+		return nil
+	}
+
+	z.SetRat(r)
+
+	return nil
+}
+
+func decodeBigRat(z *big.Rat, v reflect.Value) error {
+	r, err := bigRat(addressable(v))
+	if err != nil {
+		return err
+	}
+
+	z.Set(r)
+
+	return nil
+}
+
+// decimalPrec returns a precision large enough to hold the digits of the
+// decimal number s exactly when it is an integer, and at least 64.
+func decimalPrec(s string) uint {
+	digits := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+
+	return maxPrec(int(math.Ceil(float64(digits) * math.Log2(10))))
+}
+
+// maxPrec returns the larger of bits and 64, the default precision of
+// big.Float.
+func maxPrec(bits int) uint {
+	if bits < 64 {
+		return 64
+	}
+
+	return uint(bits)
+}
+
+// encodeBig returns a copy of v, a big.Int, big.Float or big.Rat or a
+// pointer to one of them, as a pointer. Encoding big numbers as structs
+// would lose their value, as their fields are unexported, and copying
+// them by value would share their internal buffers.
+func encodeBig(v reflect.Value) (reflect.Value, bool) {
+	switch x := v.Interface().(type) {
+	case *big.Int:
+		return reflect.ValueOf(new(big.Int).Set(x)), true
+	case *big.Float:
+		return reflect.ValueOf(new(big.Float).Copy(x)), true
+	case *big.Rat:
+		return reflect.ValueOf(new(big.Rat).Set(x)), true
+	case big.Int:
+		return reflect.ValueOf(new(big.Int).Set(&x)), true
+	case big.Float:
+		return reflect.ValueOf(new(big.Float).Copy(&x)), true
+	case big.Rat:
+		return reflect.ValueOf(new(big.Rat).Set(&x)), true
+	}
+
+	return v, false
+}
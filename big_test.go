@@ -0,0 +1,255 @@
+package mapstructure
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode_BigInt(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Value  big.Int
+		Ptr    *big.Int
+		Values []*big.Int
+	}
+
+	input := map[string]any{
+		"value": "123456789012345678901234567890",
+		"ptr":   json.Number("98765432109876543210"),
+		"values": []any{
+			42,
+			uint64(1 << 63),
+			1e20,
+			"0x10",
+			json.Number("1e3"),
+			big.NewRat(10, 2),
+			big.NewFloat(8),
+		},
+	}
+
+	var result Config
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if result.Value.String() != "123456789012345678901234567890" {
+		t.Errorf("unexpected value: %s", &result.Value)
+	}
+	if result.Ptr == nil || result.Ptr.String() != "98765432109876543210" {
+		t.Errorf("unexpected ptr: %s", result.Ptr)
+	}
+
+	expected := []string{"42", "9223372036854775808", "100000000000000000000", "16", "1000", "5", "8"}
+	actual := make([]string, len(result.Values))
+	for i, v := range result.Values {
+		actual[i] = v.String()
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestDecode_BigFloat(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Value  big.Float
+		Number *big.Float
+		Float  *big.Float
+		Int    *big.Float
+	}
+
+	input := map[string]any{
+		"value":  "123456789012345678901234567890.5",
+		"number": json.Number("1e400"),
+		"float":  0.1,
+		"int":    int64(1<<62 + 1),
+	}
+
+	var result Config
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if s := result.Value.Text('f', 1); s != "123456789012345678901234567890.5" {
+		t.Errorf("unexpected value: %s", s)
+	}
+	if s := result.Number.Text('g', 10); s != "1e+400" {
+		t.Errorf("unexpected number: %s", s)
+	}
+	if f, acc := result.Float.Float64(); f != 0.1 || acc != big.Exact {
+		t.Errorf("unexpected float: %v", f)
+	}
+	if i, acc := result.Int.Int64(); i != 1<<62+1 || acc != big.Exact {
+		t.Errorf("unexpected int: %v", i)
+	}
+}
+
+func TestDecode_BigRat(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Values []big.Rat
+	}
+
+	input := map[string]any{
+		"values": []any{"1/3", "0.1", json.Number("2.5"), 0.5, -3, big.NewInt(7)},
+	}
+
+	var result Config
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []string{"1/3", "1/10", "5/2", "1/2", "-3", "7"}
+	actual := make([]string, len(result.Values))
+	for i := range result.Values {
+		actual[i] = result.Values[i].RatString()
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestDecode_BigErrors(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Int   *big.Int
+		Float *big.Float
+		Rat   *big.Rat
+	}
+
+	cases := []struct {
+		input map[string]any
+		err   string
+	}{
+		{map[string]any{"int": 1.5}, "'Int' cannot parse value as 'big.Int': value is not an integer"},
+		{map[string]any{"int": json.Number("1.5")}, "'Int' cannot parse value as 'big.Int': value is not an integer"},
+		{map[string]any{"int": "abc"}, "'Int' cannot parse value as 'big.Int': invalid number"},
+		{map[string]any{"int": true}, "'Int' expected type 'big.Int', got unconvertible type 'bool'"},
+		{map[string]any{"float": "abc"}, "'Float' cannot parse value as 'big.Float': invalid number"},
+		{map[string]any{"rat": "1/0"}, "'Rat' cannot parse value as 'big.Rat': invalid number"},
+	}
+
+	for _, tc := range cases {
+		var result Config
+		err := Decode(tc.input, &result)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected error %q, got %v", tc.err, err)
+		}
+	}
+
+	// A big.Float with a set precision rejects floats it cannot hold.
+	result := Config{Float: new(big.Float).SetPrec(8)}
+	err := Decode(map[string]any{"float": 0.1}, &result)
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || !strings.Contains(err.Error(), "value cannot be represented exactly") {
+		t.Errorf("expected exactness error, got %v", err)
+	}
+}
+
+func TestDecode_BigEncode(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Int   big.Int
+		Float *big.Float
+		Rat   *big.Rat
+	}
+
+	input := Config{
+		Float: big.NewFloat(1.5),
+		Rat:   big.NewRat(1, 3),
+	}
+	input.Int.SetString("123456789012345678901234567890", 10)
+
+	var encoded map[string]any
+	if err := Decode(input, &encoded); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	i, ok := encoded["Int"].(*big.Int)
+	if !ok || i.Cmp(&input.Int) != 0 {
+		t.Fatalf("unexpected Int: %#v", encoded["Int"])
+	}
+	if f, ok := encoded["Float"].(*big.Float); !ok || f == input.Float || f.Cmp(input.Float) != 0 {
+		t.Fatalf("unexpected Float: %#v", encoded["Float"])
+	}
+	if r, ok := encoded["Rat"].(*big.Rat); !ok || r.Cmp(input.Rat) != 0 {
+		t.Fatalf("unexpected Rat: %#v", encoded["Rat"])
+	}
+
+	var result Config
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Int.Cmp(&input.Int) != 0 || result.Float.Cmp(input.Float) != 0 || result.Rat.Cmp(input.Rat) != 0 {
+		t.Fatalf("unexpected result: %s %s %s", &result.Int, result.Float, result.Rat)
+	}
+
+	encoded = nil
+	decoder, err := NewDecoder(&DecoderConfig{
+		EncodeTextMarshaler: true,
+		Result:              &encoded,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]any{
+		"Int":   "123456789012345678901234567890",
+		"Float": "1.5",
+		"Rat":   "1/3",
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, encoded)
+	}
+}
+
+func TestDecode_BigStructToStruct(t *testing.T) {
+	t.Parallel()
+
+	type A struct {
+		V big.Int
+		F big.Float
+		R *big.Rat
+	}
+
+	type B struct {
+		V big.Int
+		F big.Float
+		R *big.Rat
+	}
+
+	input := A{R: big.NewRat(1, 3)}
+	input.V.SetInt64(42)
+	input.F.SetFloat64(1.5)
+
+	for _, textMarshaler := range []bool{false, true} {
+		var result B
+		decoder, err := NewDecoder(&DecoderConfig{
+			EncodeTextMarshaler: textMarshaler,
+			Result:              &result,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		if err := decoder.Decode(input); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if result.V.Int64() != 42 || result.F.Cmp(&input.F) != 0 || result.R.Cmp(input.R) != 0 {
+			t.Fatalf("unexpected result: %s %s %s", &result.V, &result.F, result.R)
+		}
+	}
+}
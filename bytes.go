@@ -0,0 +1,181 @@
+package mapstructure
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// bytesEncoding is a text encoding of byte slices, such as base64 or hex.
+type bytesEncoding struct {
+	decode func(string) ([]byte, error)
+	encode func([]byte) string
+}
+
+// base64BytesEncoding returns the bytesEncoding for enc. Decoding accepts
+// input with or without padding.
+func base64BytesEncoding(enc *base64.Encoding) bytesEncoding {
+	raw := enc.WithPadding(base64.NoPadding)
+
+	return bytesEncoding{
+		decode: func(s string) ([]byte, error) {
+			return raw.DecodeString(strings.TrimRight(s, "="))
+		},
+		encode: enc.EncodeToString,
+	}
+}
+
+var hexBytesEncoding = bytesEncoding{
+	decode: hex.DecodeString,
+	encode: hex.EncodeToString,
+}
+
+// bytesEncodings are the encodings accepted by the ",encoding=" tag option.
+var bytesEncodings = map[string]bytesEncoding{
+	"base64":    base64BytesEncoding(base64.StdEncoding),
+	"base64url": base64BytesEncoding(base64.URLEncoding),
+	"hex":       hexBytesEncoding,
+}
+
+// isBytesType reports whether t is a slice or an array of bytes.
+func isBytesType(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+// decodeBytes decodes the string data into a value of t, a slice or an
+// array of bytes. Arrays require the decoded data to have their length.
+func (e bytesEncoding) decodeBytes(data any, t reflect.Type) (any, error) {
+	out := reflect.New(t).Elem()
+
+	b, err := e.decode(reflect.ValueOf(data).String())
+	if err == nil && t.Kind() == reflect.Array && len(b) != t.Len() {
+		err = fmt.Errorf("expected %d bytes, got %d", t.Len(), len(b))
+	}
+	if err != nil {
+		return nil, &ParseError{
+			Expected: out,
+			Value:    data,
+			Err:      err,
+		}
+	}
+
+	if t.Kind() == reflect.Array {
+		reflect.Copy(out, reflect.ValueOf(b))
+	} else {
+		out.Set(reflect.ValueOf(b).Convert(t))
+	}
+
+	return out.Interface(), nil
+}
+
+// encodeBytes encodes v, a slice or an array of bytes.
+func (e bytesEncoding) encodeBytes(v reflect.Value) string {
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+
+	return e.encode(b)
+}
+
+func (e bytesEncoding) decodeHook() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String || !isBytesType(t) {
+			return data, nil
+		}
+
+		return e.decodeBytes(data, t)
+	}
+}
+
+func (e bytesEncoding) encodeHook() DecodeHookFunc {
+	return func(
+		f reflect.Value,
+		t reflect.Value,
+	) (any, error) {
+		if !isBytesType(f.Type()) || !acceptsString(t.Type()) {
+			return f.Interface(), nil
+		}
+
+		return e.encodeBytes(f), nil
+	}
+}
+
+// tagOptionValue returns the value of the option "name=value" in the
+// options of tag, if any.
+func tagOptionValue(tag string, name string) string {
+	for option, rest := nextTagOption(tag); option != "" || rest != ""; option, rest = nextTagOption(rest) {
+		if len(option) > len(name) && option[len(name)] == '=' && option[:len(name)] == name {
+			return option[len(name)+1:]
+		}
+	}
+
+	return ""
+}
+
+// hasTagOption reports whether the options of tag include name.
+func hasTagOption(tag string, name string) bool {
+	for option, rest := nextTagOption(tag); option != "" || rest != ""; option, rest = nextTagOption(rest) {
+		if option == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nextTagOption returns the first option following a comma in tag and the
+// rest of tag after it, without allocating.
+func nextTagOption(tag string) (string, string) {
+	i := strings.IndexByte(tag, ',')
+	if i < 0 {
+		return "", ""
+	}
+
+	option := tag[i+1:]
+	if j := strings.IndexByte(option, ','); j >= 0 {
+		return option[:j], option[j:]
+	}
+
+	return option, ""
+}
+
+// decodeTaggedBytes decodes the string data into a value of t with the
+// encoding named in the ",encoding=" tag option of a field.
+func decodeTaggedBytes(encoding string, data any, t reflect.Type) (any, error) {
+	e, ok := bytesEncodings[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+	if !isBytesType(t) {
+		return nil, fmt.Errorf("encoding %q requires a []byte or [N]byte field, got %q", encoding, t)
+	}
+
+	if reflect.ValueOf(data).Kind() != reflect.String {
+		return data, nil
+	}
+
+	return e.decodeBytes(data, t)
+}
+
+// encodeTaggedBytes encodes v with the encoding named in the ",encoding="
+// tag option of a field.
+func encodeTaggedBytes(encoding string, v reflect.Value) (reflect.Value, error) {
+	e, ok := bytesEncodings[encoding]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown encoding %q", encoding)
+	}
+	if !isBytesType(v.Type()) {
+		return reflect.Value{}, fmt.Errorf("encoding %q requires a []byte or [N]byte field, got %q", encoding, v.Type())
+	}
+
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return v, nil
+	}
+
+	return reflect.ValueOf(e.encodeBytes(v)), nil
+}
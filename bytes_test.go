@@ -0,0 +1,216 @@
+package mapstructure
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode_EncodingTag(t *testing.T) {
+	t.Parallel()
+
+	type Keys struct {
+		Secret []byte   `mapstructure:"secret,encoding=base64"`
+		Token  []byte   `mapstructure:"token,encoding=base64url"`
+		Hash   [4]byte  `mapstructure:"hash,encoding=hex"`
+		Raw    []byte   `mapstructure:"raw"`
+		Nums   []byte   `mapstructure:"nums,encoding=hex"`
+		Empty  []byte   `mapstructure:"empty,encoding=base64"`
+		Array  [2]uint8 `mapstructure:"array,encoding=base64"`
+	}
+
+	input := map[string]any{
+		"secret": "aGVsbG8=",
+		"token":  "-_8",
+		"hash":   "deadbeef",
+		"raw":    []byte("raw"),
+		"nums":   []any{1, 2},
+		"array":  "AQI",
+	}
+
+	var result Keys
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Keys{
+		Secret: []byte("hello"),
+		Token:  []byte{0xfb, 0xff},
+		Hash:   [4]byte{0xde, 0xad, 0xbe, 0xef},
+		Raw:    []byte("raw"),
+		Nums:   []byte{1, 2},
+		Array:  [2]uint8{1, 2},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+
+	var encoded map[string]any
+	if err := Decode(result, &encoded); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expectedMap := map[string]any{
+		"secret": "aGVsbG8=",
+		"token":  "-_8=",
+		"hash":   "deadbeef",
+		"raw":    []byte("raw"),
+		"nums":   "0102",
+		"empty":  []byte(nil),
+		"array":  "AQI=",
+	}
+	if !reflect.DeepEqual(encoded, expectedMap) {
+		t.Fatalf("expected %#v, got %#v", expectedMap, encoded)
+	}
+
+	var roundTrip Keys
+	if err := Decode(encoded, &roundTrip); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(roundTrip, result) {
+		t.Fatalf("expected %#v, got %#v", result, roundTrip)
+	}
+}
+
+func TestDecode_EncodingTagErrors(t *testing.T) {
+	t.Parallel()
+
+	type Keys struct {
+		Hash   [4]byte `mapstructure:"hash,encoding=hex"`
+		Secret []byte  `mapstructure:"secret,encoding=base64"`
+	}
+
+	cases := []struct {
+		input map[string]any
+		err   string
+	}{
+		{map[string]any{"hash": "dead"}, "'hash' cannot parse value as '[4]uint8': expected 4 bytes, got 2"},
+		{map[string]any{"hash": "zz"}, "'hash' cannot parse value as '[4]uint8': encoding/hex: invalid byte"},
+		{map[string]any{"secret": "!!!"}, "'secret' cannot parse value as '[]uint8': illegal base64 data"},
+	}
+
+	for _, tc := range cases {
+		var result Keys
+		err := Decode(tc.input, &result)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected error %q, got %v", tc.err, err)
+		}
+
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("expected ParseError, got %T", err)
+		}
+	}
+
+	type Invalid struct {
+		Name string `mapstructure:"name,encoding=base64"`
+		Key  []byte `mapstructure:"key,encoding=rot13"`
+	}
+
+	var invalid Invalid
+	err := Decode(map[string]any{"name": "x", "key": "x"}, &invalid)
+	if err == nil ||
+		!strings.Contains(err.Error(), `'name' encoding "base64" requires a []byte or [N]byte field, got "string"`) ||
+		!strings.Contains(err.Error(), `'key' unknown encoding "rot13"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var encoded map[string]any
+	err = Decode(Invalid{Key: []byte("x")}, &encoded)
+	if err == nil || !strings.Contains(err.Error(), `'Name' encoding "base64" requires a []byte or [N]byte field`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDecode_EncodingTagAfterHook(t *testing.T) {
+	t.Parallel()
+
+	type Keys struct {
+		Secret []byte `mapstructure:"secret,encoding=base64"`
+		Plain  []byte `mapstructure:"plain"`
+	}
+
+	var resolvers Resolvers
+	resolvers.Register("secret", MapResolver(map[string]string{"key": "aGVsbG8="}))
+
+	var result Keys
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: resolvers.DecodeHook(),
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(map[string]any{"secret": "secret:key", "plain": []byte("x")}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(result.Secret) != "hello" || string(result.Plain) != "x" {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
+func TestBytesHookPairs(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Key  []byte
+		Hash [2]byte
+	}
+
+	input := Config{Key: []byte{0xfb, 0xff}, Hash: [2]byte{0xab, 0xcd}}
+
+	for _, pair := range []struct {
+		hooks    HookPair
+		expected map[string]any
+	}{
+		{Base64HookPair(base64.RawURLEncoding), map[string]any{"Key": "-_8", "Hash": "q80"}},
+		{Base64HookPair(base64.StdEncoding), map[string]any{"Key": "+/8=", "Hash": "q80="}},
+		{HexHookPair(), map[string]any{"Key": "fbff", "Hash": "abcd"}},
+	} {
+		var encoded map[string]any
+		config := &DecoderConfig{Result: &encoded}
+		config.AddHookPairs(pair.hooks)
+
+		decoder, err := NewDecoder(config)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if err := decoder.Decode(input); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !reflect.DeepEqual(encoded, pair.expected) {
+			t.Fatalf("expected %#v, got %#v", pair.expected, encoded)
+		}
+
+		var result Config
+		config = &DecoderConfig{Result: &result}
+		config.AddHookPairs(pair.hooks)
+
+		decoder, err = NewDecoder(config)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if err := decoder.Decode(encoded); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !reflect.DeepEqual(result, input) {
+			t.Fatalf("expected %#v, got %#v", input, result)
+		}
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: HexToBytesHookFunc(),
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	err = decoder.Decode(map[string]any{"hash": "abcdef"})
+	if err == nil || !strings.Contains(err.Error(), "'Hash' cannot parse value as '[2]uint8': expected 2 bytes, got 3") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
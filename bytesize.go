@@ -0,0 +1,180 @@
+package mapstructure
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes. It is decoded from strings such as
+// "512MiB", "10GB" or "1.5k" by StringToByteSizeHookFunc and encoded back
+// to such a string by ByteSizeToStringHookFunc.
+type ByteSize uint64
+
+// Byte size units. SI units are powers of 1000, IEC units powers of 1024.
+const (
+	Byte ByteSize = 1
+
+	Kilobyte = 1000 * Byte
+	Megabyte = 1000 * Kilobyte
+	Gigabyte = 1000 * Megabyte
+	Terabyte = 1000 * Gigabyte
+	Petabyte = 1000 * Terabyte
+	Exabyte  = 1000 * Petabyte
+
+	Kibibyte = 1024 * Byte
+	Mebibyte = 1024 * Kibibyte
+	Gibibyte = 1024 * Mebibyte
+	Tebibyte = 1024 * Gibibyte
+	Pebibyte = 1024 * Tebibyte
+	Exbibyte = 1024 * Pebibyte
+)
+
+type byteSizeUnit struct {
+	name string
+	size ByteSize
+}
+
+// byteSizeUnits lists the units in the order used to format sizes:
+// larger units first, so that the shortest exact representation wins.
+var byteSizeUnits = []byteSizeUnit{
+	{"EiB", Exbibyte},
+	{"EB", Exabyte},
+	{"PiB", Pebibyte},
+	{"PB", Petabyte},
+	{"TiB", Tebibyte},
+	{"TB", Terabyte},
+	{"GiB", Gibibyte},
+	{"GB", Gigabyte},
+	{"MiB", Mebibyte},
+	{"MB", Megabyte},
+	{"KiB", Kibibyte},
+	{"kB", Kilobyte},
+}
+
+// byteSizeSuffixes maps the lower case unit suffixes accepted by
+// ParseByteSize to their size. The trailing "B" is optional.
+var byteSizeSuffixes = map[string]ByteSize{
+	"":   Byte,
+	"b":  Byte,
+	"k":  Kilobyte,
+	"kb": Kilobyte,
+	"m":  Megabyte,
+	"mb": Megabyte,
+	"g":  Gigabyte,
+	"gb": Gigabyte,
+	"t":  Terabyte,
+	"tb": Terabyte,
+	"p":  Petabyte,
+	"pb": Petabyte,
+	"e":  Exabyte,
+	"eb": Exabyte,
+
+	"ki":  Kibibyte,
+	"kib": Kibibyte,
+	"mi":  Mebibyte,
+	"mib": Mebibyte,
+	"gi":  Gibibyte,
+	"gib": Gibibyte,
+	"ti":  Tebibyte,
+	"tib": Tebibyte,
+	"pi":  Pebibyte,
+	"pib": Pebibyte,
+	"ei":  Exbibyte,
+	"eib": Exbibyte,
+}
+
+// ParseByteSize parses a byte size such as "512MiB", "10GB", "1.5k" or
+// "1024". The number may be an integer or a decimal, optionally followed
+// by a case insensitive SI (k, M, G, T, P, E) or IEC (Ki, Mi, Gi, Ti, Pi,
+// Ei) unit, with or without a trailing "B". The result must be a whole
+// number of bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	n, err := parseByteSize(s, false)
+	if err != nil {
+		return 0, err
+	}
+
+	if !n.IsUint64() {
+		return 0, strconv.ErrRange
+	}
+
+	return ByteSize(n.Uint64()), nil
+}
+
+// parseByteSize parses s as for ParseByteSize, allowing a leading sign if
+// signed is set.
+func parseByteSize(s string, signed bool) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+
+	neg := false
+	if signed && strings.HasPrefix(s, "-") {
+		neg = true
+		s = s[1:]
+	}
+
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end < 0 {
+		end = len(s)
+	}
+
+	number := s[:end]
+	unit, ok := byteSizeSuffixes[strings.ToLower(strings.TrimSpace(s[end:]))]
+	if !ok {
+		return nil, errors.New("unknown unit")
+	}
+
+	whole, frac, _ := strings.Cut(number, ".")
+	if whole == "" && frac == "" || strings.Contains(frac, ".") {
+		return nil, errors.New("invalid number")
+	}
+
+	n, ok := new(big.Int).SetString(whole+frac, 10)
+	if !ok {
+		return nil, errors.New("invalid number")
+	}
+
+	n.Mul(n, new(big.Int).SetUint64(uint64(unit)))
+
+	div := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(len(frac))), nil)
+	n, rem := n.QuoRem(n, div, new(big.Int))
+	if rem.Sign() != 0 {
+		return nil, errors.New("not a whole number of bytes")
+	}
+
+	if neg {
+		n.Neg(n)
+	}
+
+	return n, nil
+}
+
+// String formats b with the largest unit dividing it exactly, such as
+// "512MiB" or "10GB", or as a number of bytes such as "1500B".
+func (b ByteSize) String() string {
+	for _, u := range byteSizeUnits {
+		if b >= u.size && b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+// byteSizeInRange reports whether n fits in an integer of the given bit
+// size.
+func byteSizeInRange(n *big.Int, bits int, signed bool) bool {
+	if !signed {
+		return n.Sign() >= 0 && n.BitLen() <= bits
+	}
+
+	if n.IsInt64() {
+		i := n.Int64()
+		return i >= -1<<(bits-1) && i <= 1<<(bits-1)-1
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+package mapstructure
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		input    string
+		expected ByteSize
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"10B", 10},
+		{"1.5k", 1500},
+		{"1.5K", 1500},
+		{"10GB", 10 * Gigabyte},
+		{"10 GB", 10 * Gigabyte},
+		{"512MiB", 512 * Mebibyte},
+		{"512mi", 512 * Mebibyte},
+		{"0.5KiB", 512},
+		{".5kb", 500},
+		{"16EiB", 0},
+		{"15EiB", 15 * Exbibyte},
+		{"18446744073709551615", 1<<64 - 1},
+	}
+
+	for _, tc := range cases {
+		actual, err := ParseByteSize(tc.input)
+		if tc.input == "16EiB" {
+			if !errors.Is(err, strconv.ErrRange) {
+				t.Errorf("%q: expected range error, got %v", tc.input, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tc.input, err)
+			continue
+		}
+		if actual != tc.expected {
+			t.Errorf("%q: expected %d, got %d", tc.input, tc.expected, actual)
+		}
+	}
+
+	for _, input := range []string{"", "k", "1.2.3", "1 XB", "-1", "0.3KiB", "1e3"} {
+		if _, err := ParseByteSize(input); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		input    ByteSize
+		expected string
+	}{
+		{0, "0B"},
+		{1500, "1500B"},
+		{512 * Mebibyte, "512MiB"},
+		{10 * Gigabyte, "10GB"},
+		{1536 * Kibibyte, "1536KiB"},
+		{2 * Kilobyte, "2kB"},
+	}
+
+	for _, tc := range cases {
+		if actual := tc.input.String(); actual != tc.expected {
+			t.Errorf("%d: expected %q, got %q", uint64(tc.input), tc.expected, actual)
+		}
+
+		parsed, err := ParseByteSize(tc.expected)
+		if err != nil || parsed != tc.input {
+			t.Errorf("%q: round trip failed: %d, %v", tc.expected, parsed, err)
+		}
+	}
+}
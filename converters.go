@@ -0,0 +1,82 @@
+package mapstructure
+
+import (
+	"reflect"
+)
+
+// Converters is a registry of conversion functions keyed by source and
+// target type. Unlike decode hooks, which are all called for every value,
+// the decoder only calls the converter registered for the exact pair of
+// input and target types, found with a single map lookup. See
+// DecoderConfig.Converters.
+//
+// The zero value is an empty registry ready to use. A Converters must not
+// be modified while a Decoder is using it.
+type Converters struct {
+	funcs map[converterKey]func(reflect.Value) (reflect.Value, error)
+}
+
+type converterKey struct {
+	from reflect.Type
+	to   reflect.Type
+}
+
+// Register registers fn as the conversion from From to To in c, replacing
+// any conversion previously registered for the same pair of types.
+//
+//	var c Converters
+//	Register(&c, func(s string) (time.Duration, error) {
+//	    return time.ParseDuration(s)
+//	})
+func Register[From any, To any](c *Converters, fn func(From) (To, error)) {
+	var from From
+	var to To
+	key := converterKey{
+		from: reflect.TypeOf(&from).Elem(),
+		to:   reflect.TypeOf(&to).Elem(),
+	}
+
+	if c.funcs == nil {
+		c.funcs = make(map[converterKey]func(reflect.Value) (reflect.Value, error))
+	}
+
+	c.funcs[key] = func(v reflect.Value) (reflect.Value, error) {
+		out, err := fn(v.Interface().(From))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return reflect.ValueOf(&out).Elem(), nil
+	}
+}
+
+// lookup returns the conversion registered from the type from to the
+// type to, if any.
+func (c *Converters) lookup(from, to reflect.Type) (func(reflect.Value) (reflect.Value, error), bool) {
+	if c == nil || c.funcs == nil {
+		return nil, false
+	}
+
+	fn, ok := c.funcs[converterKey{from, to}]
+
+	return fn, ok
+}
+
+// DecodeHook returns a DecodeHookFunc applying the registered conversions,
+// so that they can be combined with other hooks using
+// ComposeDecodeHookFunc.
+func (c *Converters) DecodeHook() DecodeHookFunc {
+	return func(f reflect.Value, t reflect.Value) (any, error) {
+		fn, ok := c.lookup(f.Type(), t.Type())
+		if !ok {
+			return f.Interface(), nil
+		}
+
+		out, err := fn(f)
+		if err != nil {
+			return nil, err
+		}
+
+		return out.Interface(), nil
+	}
+}
@@ -0,0 +1,137 @@
+package mapstructure
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestConverters(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Timeout time.Duration
+		Port    int
+		Name    string
+	}
+
+	var c Converters
+	Register(&c, func(s string) (time.Duration, error) {
+		return time.ParseDuration(s)
+	})
+	Register(&c, func(s string) (int, error) {
+		return strconv.Atoi(s)
+	})
+
+	input := map[string]any{
+		"timeout": "5s",
+		"port":    "8080",
+		"name":    "app",
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		Converters: &c,
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{Timeout: 5 * time.Second, Port: 8080, Name: "app"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+}
+
+func TestConverters_error(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Port int
+	}
+
+	var c Converters
+	Register(&c, func(s string) (int, error) {
+		return 0, errors.New("invalid port")
+	})
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		Converters: &c,
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{"port": "x"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var derr *DecodeError
+	if !errors.As(err, &derr) || derr.Name() != "Port" {
+		t.Fatalf("expected DecodeError for 'Port', got %#v", err)
+	}
+}
+
+func TestConverters_interfaceTarget(t *testing.T) {
+	t.Parallel()
+
+	var c Converters
+	Register(&c, func(s string) (any, error) {
+		return "converted " + s, nil
+	})
+
+	var result any
+	decoder, err := NewDecoder(&DecoderConfig{
+		Converters: &c,
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode("value"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if result != "converted value" {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+}
+
+func TestConverters_DecodeHook(t *testing.T) {
+	t.Parallel()
+
+	var c Converters
+	Register(&c, func(s string) (time.Duration, error) {
+		return time.ParseDuration(s)
+	})
+
+	hook := ComposeDecodeHookFunc(c.DecodeHook())
+
+	actual, err := DecodeHookExec(hook, reflect.ValueOf("1m"), reflect.ValueOf(time.Duration(0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if actual != time.Minute {
+		t.Fatalf("expected 1m, got %#v", actual)
+	}
+
+	// Unregistered pairs pass through.
+	actual, err = DecodeHookExec(hook, reflect.ValueOf("1m"), reflect.ValueOf(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if actual != "1m" {
+		t.Fatalf("expected 1m, got %#v", actual)
+	}
+}
@@ -2,15 +2,20 @@ package mapstructure
 
 import (
 	"encoding"
-	"errors"
+	"encoding/base64"
 	"fmt"
 	"net"
+	"net/mail"
 	"net/netip"
 	"net/url"
+	"os"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/go-viper/mapstructure/v2/internal/errors"
 )
 
 // typedDecodeHook takes a raw DecodeHookFunc (an any) and turns
@@ -20,10 +25,11 @@ func typedDecodeHook(h DecodeHookFunc) DecodeHookFunc {
 	var f1 DecodeHookFuncType
 	var f2 DecodeHookFuncKind
 	var f3 DecodeHookFuncValue
+	var f4 DecodeHookFuncPath
 
 	// Fill in the variables into this interface and the rest is done
 	// automatically using the reflect package.
-	potential := []any{f1, f2, f3}
+	potential := []any{f1, f2, f3, f4}
 
 	v := reflect.ValueOf(h)
 	vt := v.Type()
@@ -40,22 +46,24 @@ func typedDecodeHook(h DecodeHookFunc) DecodeHookFunc {
 // cachedDecodeHook takes a raw DecodeHookFunc (an any) and turns
 // it into a closure to be used directly
 // if the type fails to convert we return a closure always erroring to keep the previous behaviour
-func cachedDecodeHook(raw DecodeHookFunc) func(from reflect.Value, to reflect.Value) (any, error) {
+func cachedDecodeHook(raw DecodeHookFunc) func(path string, from reflect.Value, to reflect.Value) (any, error) {
 	switch f := typedDecodeHook(raw).(type) {
 	case DecodeHookFuncType:
-		return func(from reflect.Value, to reflect.Value) (any, error) {
+		return func(_ string, from reflect.Value, to reflect.Value) (any, error) {
 			return f(from.Type(), to.Type(), from.Interface())
 		}
 	case DecodeHookFuncKind:
-		return func(from reflect.Value, to reflect.Value) (any, error) {
+		return func(_ string, from reflect.Value, to reflect.Value) (any, error) {
 			return f(from.Kind(), to.Kind(), from.Interface())
 		}
 	case DecodeHookFuncValue:
-		return func(from reflect.Value, to reflect.Value) (any, error) {
+		return func(_ string, from reflect.Value, to reflect.Value) (any, error) {
 			return f(from, to)
 		}
+	case DecodeHookFuncPath:
+		return f
 	default:
-		return func(from reflect.Value, to reflect.Value) (any, error) {
+		return func(string, reflect.Value, reflect.Value) (any, error) {
 			return nil, errors.New("invalid decode hook signature")
 		}
 	}
@@ -75,6 +83,8 @@ func DecodeHookExec(
 		return f(from.Kind(), to.Kind(), from.Interface())
 	case DecodeHookFuncValue:
 		return f(from, to)
+	case DecodeHookFuncPath:
+		return f("", from, to)
 	default:
 		return nil, errors.New("invalid decode hook signature")
 	}
@@ -85,18 +95,24 @@ func DecodeHookExec(
 //
 // The composed funcs are called in order, with the result of the
 // previous transformation.
+//
+// Compatibility note: the returned DecodeHookFunc is a DecodeHookFuncPath,
+// so that the path of the decoded value reaches the composed funcs. Before
+// DecodeHookFuncPath was added, it was a func(reflect.Value, reflect.Value)
+// (any, error), and type assertions to that type now fail. Callers should
+// assert DecodeHookFuncPath instead, or call the result with DecodeHookExec.
 func ComposeDecodeHookFunc(fs ...DecodeHookFunc) DecodeHookFunc {
-	cached := make([]func(from reflect.Value, to reflect.Value) (any, error), 0, len(fs))
+	cached := make([]func(path string, from reflect.Value, to reflect.Value) (any, error), 0, len(fs))
 	for _, f := range fs {
 		cached = append(cached, cachedDecodeHook(f))
 	}
-	return func(f reflect.Value, t reflect.Value) (any, error) {
+	return DecodeHookFuncPath(func(path string, f reflect.Value, t reflect.Value) (any, error) {
 		var err error
 		data := f.Interface()
 
 		newFrom := f
 		for _, c := range cached {
-			data, err = c(newFrom, t)
+			data, err = c(path, newFrom, t)
 			if err != nil {
 				return nil, err
 			}
@@ -108,35 +124,103 @@ func ComposeDecodeHookFunc(fs ...DecodeHookFunc) DecodeHookFunc {
 		}
 
 		return data, nil
-	}
+	})
 }
 
 // OrComposeDecodeHookFunc executes all input hook functions until one of them returns no error. In that case its value is returned.
-// If all hooks return an error, OrComposeDecodeHookFunc returns an error concatenating all error messages.
+// If all hooks return an error, OrComposeDecodeHookFunc returns a single error wrapping them, each
+// wrapped in a HookError holding the index of its hook, and its name if named with NamedDecodeHookFunc.
+// The wrapped errors are available through its Unwrap() []error method.
+//
+// Compatibility note: as for ComposeDecodeHookFunc, the returned DecodeHookFunc is now a DecodeHookFuncPath
+// rather than a func(reflect.Value, reflect.Value) (any, error).
 func OrComposeDecodeHookFunc(ff ...DecodeHookFunc) DecodeHookFunc {
-	cached := make([]func(from reflect.Value, to reflect.Value) (any, error), 0, len(ff))
+	cached := make([]func(path string, from reflect.Value, to reflect.Value) (any, error), 0, len(ff))
 	for _, f := range ff {
 		cached = append(cached, cachedDecodeHook(f))
 	}
-	return func(a, b reflect.Value) (any, error) {
-		var allErrs string
-		var out any
-		var err error
+	return DecodeHookFuncPath(func(path string, a, b reflect.Value) (any, error) {
+		var errs []error
 
-		for _, c := range cached {
-			out, err = c(a, b)
+		for i, c := range cached {
+			out, err := c(path, a, b)
 			if err != nil {
-				allErrs += err.Error() + "\n"
+				errs = append(errs, newHookError("", i, err))
 				continue
 			}
 
 			return out, nil
 		}
 
-		return nil, errors.New(allErrs)
+		return nil, &hookErrors{errs: errs}
+	})
+}
+
+// NamedDecodeHookFunc returns a DecodeHookFunc that wraps the errors of
+// hook in a HookError holding name, so that a failing hook can be told
+// apart from the others composed with it.
+//
+//	ComposeDecodeHookFunc(
+//	    NamedDecodeHookFunc("duration", StringToTimeDurationHookFunc()),
+//	    NamedDecodeHookFunc("url", StringToURLHookFunc()),
+//	)
+func NamedDecodeHookFunc(name string, hook DecodeHookFunc) DecodeHookFunc {
+	cached := cachedDecodeHook(hook)
+
+	return func(path string, f reflect.Value, t reflect.Value) (any, error) {
+		out, err := cached(path, f, t)
+		if err != nil {
+			return nil, newHookError(name, -1, err)
+		}
+
+		return out, nil
+	}
+}
+
+// HookWhen returns a DecodeHookFunc that applies hook only to the values
+// for which predicate returns true, given their path and the source and
+// target values. Other values are left unchanged.
+func HookWhen(predicate func(path string, from reflect.Value, to reflect.Value) bool, hook DecodeHookFunc) DecodeHookFunc {
+	cached := cachedDecodeHook(hook)
+
+	return func(path string, f reflect.Value, t reflect.Value) (any, error) {
+		if !predicate(path, f, t) {
+			return f.Interface(), nil
+		}
+
+		return cached(path, f, t)
 	}
 }
 
+// HookForType returns a DecodeHookFunc that applies hook only when
+// decoding into a value of type T.
+//
+//	HookForType[time.Duration](ExtendedDurationHookFunc(DurationDays, 0))
+func HookForType[T any](hook DecodeHookFunc) DecodeHookFunc {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	return HookWhen(func(_ string, _ reflect.Value, t reflect.Value) bool {
+		return t.Type() == typ
+	}, hook)
+}
+
+// HookForPath returns a DecodeHookFunc that applies hook only to the
+// values whose path matches pattern, such as "servers.*.tls" for the
+// "TLS" field of each element of "Servers". Segments of the pattern are
+// separated by dots or enclosed in brackets, as in "servers[*].tls", and
+// are matched case-insensitively with path.Match; a "**" segment matches
+// any number of segments. An empty pattern matches the root value.
+//
+// As in errors, map keys are decoded with the path of their entry, such
+// as "Env[home]"; compose with HookForType to apply hook to values only.
+func HookForPath(pattern string, hook DecodeHookFunc) DecodeHookFunc {
+	segments := splitPath(pattern)
+
+	return HookWhen(func(path string, _ reflect.Value, _ reflect.Value) bool {
+		return matchPath(segments, path)
+	}, hook)
+}
+
 // StringToSliceHookFunc returns a DecodeHookFunc that converts
 // string to []string by splitting on the given sep.
 func StringToSliceHookFunc(sep string) DecodeHookFunc {
@@ -184,6 +268,93 @@ func StringToWeakSliceHookFunc(sep string) DecodeHookFunc {
 	}
 }
 
+// StringToTypedSliceHookFunc returns a DecodeHookFunc that splits strings
+// on sep into the elements of any slice or array, such as "10s, 1m" into
+// []time.Duration. Elements are split like the fields of a CSV record:
+// they may be enclosed in double quotes to contain sep, a doubled quote
+// standing for a quote, and spaces around them are trimmed.
+//
+// Each element is then decoded as a string into its type, with the other
+// hooks and an error path such as "Timeouts[1]". Compose with
+// StringToBasicTypeHookFunc, or enable WeaklyTypedInput, to decode
+// numbers and booleans. Byte slices and arrays are left unchanged.
+func StringToTypedSliceHookFunc(sep string) DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array || isBytesType(t) {
+			return data, nil
+		}
+
+		raw := reflect.ValueOf(data).String()
+		if strings.TrimSpace(raw) == "" {
+			return []string{}, nil
+		}
+
+		return splitFields(raw, sep)
+	}
+}
+
+// StringToMapHookFunc returns a DecodeHookFunc that converts strings such
+// as "team=core,env=prod" to maps with string keys, splitting pairs on
+// pairSep and keys from values on kvSep. Each value is then decoded into
+// the element type of the map. Keys and values may be enclosed in double
+// quotes to contain separators, as in `msg="a,b"`, and a backslash escapes
+// the character following it. Duplicate keys, and empty separators, are
+// an error.
+func StringToMapHookFunc(pairSep, kvSep string) DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+			return data, nil
+		}
+
+		return parseStringMap(reflect.ValueOf(data).String(), pairSep, kvSep)
+	}
+}
+
+// JSONStringHookFunc returns a DecodeHookFunc that parses JSON objects and
+// arrays embedded in strings, such as "{\"a\":1}", when decoding into a
+// struct, map or slice. The parsed value is then decoded as if it had been
+// in the input, numbers being decoded exactly.
+//
+// A json.RawMessage is parsed the same way when decoding into a struct,
+// map or slice. When decoding into a json.RawMessage, strings holding
+// valid JSON are used as is and any other value is marshaled to JSON.
+func JSONStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		switch {
+		case t == jsonRawMessageType:
+			if f == t {
+				return data, nil
+			}
+		case !isJSONContainer(t):
+			return data, nil
+		case f == jsonRawMessageType:
+		case f.Kind() == reflect.String && isJSONText(reflect.ValueOf(data).String()):
+		default:
+			return data, nil
+		}
+
+		return decodeJSON(data, t)
+	}
+}
+
 // StringToTimeDurationHookFunc returns a DecodeHookFunc that converts
 // strings to time.Duration.
 func StringToTimeDurationHookFunc() DecodeHookFunc {
@@ -206,6 +377,43 @@ func StringToTimeDurationHookFunc() DecodeHookFunc {
 	}
 }
 
+// ExtendedDurationHookFunc returns a DecodeHookFunc that converts strings
+// to time.Duration like StringToTimeDurationHookFunc, additionally
+// accepting the given forms. With DurationNumbers, integers, floats and
+// json.Number are converted as well, as a number of unit, which defaults
+// to time.Second if zero.
+//
+//	ExtendedDurationHookFunc(DurationDays|DurationISO8601|DurationNumbers, time.Second)
+func ExtendedDurationHookFunc(forms DurationForms, unit time.Duration) DecodeHookFunc {
+	if unit == 0 {
+		unit = time.Second
+	}
+
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if t != reflect.TypeOf(time.Duration(5)) || f == t {
+			return data, nil
+		}
+
+		d, ok, err := extendedDuration(data, forms, unit)
+		if !ok {
+			return data, nil
+		}
+		if err != nil {
+			return nil, &ParseError{
+				Expected: reflect.New(t).Elem(),
+				Value:    data,
+				Err:      err,
+			}
+		}
+
+		return d, nil
+	}
+}
+
 // StringToTimeLocationHookFunc returns a DecodeHookFunc that converts
 // strings to *time.Location.
 func StringToTimeLocationHookFunc() DecodeHookFunc {
@@ -316,6 +524,43 @@ func StringToTimeHookFunc(layout string) DecodeHookFunc {
 	}
 }
 
+// TimeHookFunc returns a DecodeHookFunc that converts strings to
+// time.Time by trying each of the layouts of config in order and, if
+// config.Epoch is set, Unix timestamps given as integers, floats,
+// json.Number or numeric strings. If no layout matches, the returned
+// ParseError lists all the layouts tried.
+//
+//	TimeHookFunc(TimeHookConfig{
+//	    Layouts:  []string{time.RFC3339Nano, "2006-01-02 15:04:05"},
+//	    Epoch:    time.Millisecond,
+//	    Location: time.Local,
+//	})
+func TimeHookFunc(config TimeHookConfig) DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if t != reflect.TypeOf(time.Time{}) || f == t {
+			return data, nil
+		}
+
+		ti, ok, err := config.convertTime(data)
+		if !ok {
+			return data, nil
+		}
+		if err != nil {
+			return nil, &ParseError{
+				Expected: reflect.New(t).Elem(),
+				Value:    data,
+				Err:      err,
+			}
+		}
+
+		return ti, nil
+	}
+}
+
 // WeaklyTypedHook is a DecodeHookFunc which adds support for weak typing to
 // the decoder.
 //
@@ -465,6 +710,753 @@ func StringToNetIPPrefixHookFunc() DecodeHookFunc {
 	}
 }
 
+// StringToByteSizeHookFunc returns a DecodeHookFunc that converts byte
+// sizes such as "512MiB", "10GB" or "1.5k" to ByteSize, as parsed by
+// ParseByteSize. Sizes that do not fit are reported as a ParseError
+// wrapping strconv.ErrRange.
+func StringToByteSizeHookFunc() DecodeHookFunc {
+	byteSizeType := reflect.TypeOf(ByteSize(0))
+
+	return stringToByteSizeHookFunc(func(t reflect.Type) bool {
+		return t == byteSizeType
+	})
+}
+
+// StringToIntByteSizeHookFunc returns a DecodeHookFunc that converts byte
+// sizes like StringToByteSizeHookFunc to the predeclared integer types,
+// such as int64 or uint32. Signed targets also accept negative sizes.
+// Named integer types, such as time.Duration or enums, are left to their
+// own hooks.
+func StringToIntByteSizeHookFunc() DecodeHookFunc {
+	return stringToByteSizeHookFunc(func(t reflect.Type) bool {
+		return t.PkgPath() == "" && t.Name() != ""
+	})
+}
+
+func stringToByteSizeHookFunc(accept func(reflect.Type) bool) DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String || !accept(t) {
+			return data, nil
+		}
+
+		var signed bool
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			signed = true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			return data, nil
+		}
+
+		out := reflect.New(t).Elem()
+		n, err := parseByteSize(reflect.ValueOf(data).String(), signed)
+		if err == nil && !byteSizeInRange(n, t.Bits(), signed) {
+			err = strconv.ErrRange
+		}
+		if err != nil {
+			return nil, &ParseError{
+				Expected: out,
+				Value:    data,
+				Err:      err,
+			}
+		}
+
+		if signed {
+			out.SetInt(n.Int64())
+		} else {
+			out.SetUint(n.Uint64())
+		}
+
+		return out.Interface(), nil
+	}
+}
+
+// Base64ToBytesHookFunc returns a DecodeHookFunc that decodes base64
+// strings into []byte or [N]byte using enc, such as base64.StdEncoding or
+// base64.URLEncoding. Padding is optional. Arrays require the decoded data
+// to have their exact length.
+func Base64ToBytesHookFunc(enc *base64.Encoding) DecodeHookFunc {
+	return base64BytesEncoding(enc).decodeHook()
+}
+
+// HexToBytesHookFunc returns a DecodeHookFunc that decodes hex strings
+// into []byte or [N]byte. Arrays require the decoded data to have their
+// exact length.
+func HexToBytesHookFunc() DecodeHookFunc {
+	return hexBytesEncoding.decodeHook()
+}
+
+// StringToRegexpHookFunc returns a DecodeHookFunc that compiles strings
+// into *regexp.Regexp or regexp.Regexp using regexp.Compile.
+func StringToRegexpHookFunc() DecodeHookFunc {
+	return stringToRegexpHookFunc(regexp.Compile)
+}
+
+// StringToPOSIXRegexpHookFunc returns a DecodeHookFunc that compiles
+// strings into *regexp.Regexp or regexp.Regexp using
+// regexp.CompilePOSIX.
+func StringToPOSIXRegexpHookFunc() DecodeHookFunc {
+	return stringToRegexpHookFunc(regexp.CompilePOSIX)
+}
+
+func stringToRegexpHookFunc(compile func(string) (*regexp.Regexp, error)) DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		if t != reflect.TypeOf(&regexp.Regexp{}) && t != reflect.TypeOf(regexp.Regexp{}) {
+			return data, nil
+		}
+
+		re, err := compile(reflect.ValueOf(data).String())
+		if err != nil {
+			return nil, &ParseError{
+				Expected: reflect.New(t).Elem(),
+				Value:    data,
+				Err:      wrapRegexpSyntaxError(err),
+			}
+		}
+
+		if t.Kind() == reflect.Ptr {
+			return re, nil
+		}
+
+		return *re, nil
+	}
+}
+
+// ExpandEnvHookFunc returns a DecodeHookFunc that expands references to
+// environment variables in strings before they are decoded:
+//
+//   - ${VAR} is replaced by the value of VAR, or an empty string if unset.
+//   - ${VAR:-default} is replaced by default if VAR is unset or empty.
+//   - ${VAR:?message} fails with message if VAR is unset or empty.
+//   - $${ is replaced by a literal ${.
+//
+// Unlike os.ExpandEnv, dollar signs that are not followed by a brace are
+// left alone. Variables are looked up with lookup, which defaults to
+// os.LookupEnv if nil.
+func ExpandEnvHookFunc(lookup func(string) (string, bool)) DecodeHookFunc {
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	return func(
+		f reflect.Value,
+		t reflect.Value,
+	) (any, error) {
+		if f.Kind() != reflect.String {
+			return f.Interface(), nil
+		}
+
+		s, err := expandEnv(f.String(), lookup)
+		if err != nil {
+			return nil, err
+		}
+
+		return reflect.ValueOf(s).Convert(f.Type()).Interface(), nil
+	}
+}
+
+// StringToFileModeHookFunc returns a DecodeHookFunc that converts octal
+// strings such as "0644", "644" or "0o755" to os.FileMode. The setuid,
+// setgid and sticky bits ("4755", "2755", "1777") are converted to
+// os.ModeSetuid, os.ModeSetgid and os.ModeSticky.
+func StringToFileModeHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		if t != reflect.TypeOf(os.FileMode(0)) {
+			return data, nil
+		}
+
+		// Convert it by parsing
+		s := reflect.ValueOf(data).String()
+		if len(s) > 2 && s[0] == '0' && (s[1] == 'o' || s[1] == 'O') {
+			s = s[2:]
+		}
+		u, err := strconv.ParseUint(s, 8, 12)
+		if err != nil {
+			return os.FileMode(0), wrapStrconvNumError(err)
+		}
+
+		mode := os.FileMode(u) & os.ModePerm
+		if u&0o4000 != 0 {
+			mode |= os.ModeSetuid
+		}
+		if u&0o2000 != 0 {
+			mode |= os.ModeSetgid
+		}
+		if u&0o1000 != 0 {
+			mode |= os.ModeSticky
+		}
+
+		return mode, nil
+	}
+}
+
+// StringToTimeMonthHookFunc returns a DecodeHookFunc that converts month
+// names such as "January" or "jan", case-insensitively, and numbers from 1
+// to 12 to time.Month.
+func StringToTimeMonthHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		if t != reflect.TypeOf(time.Month(1)) {
+			return data, nil
+		}
+
+		// Convert it by parsing
+		m, ok := parseTimeName(reflect.ValueOf(data).String(), 1, 12, func(i int) string {
+			return time.Month(i).String()
+		})
+		if !ok {
+			return nil, &ParseError{
+				Expected: reflect.New(t).Elem(),
+				Value:    data,
+				Err:      errors.New("invalid month"),
+			}
+		}
+
+		return time.Month(m), nil
+	}
+}
+
+// StringToTimeWeekdayHookFunc returns a DecodeHookFunc that converts day
+// names such as "Monday" or "mon", case-insensitively, and numbers from 0
+// (Sunday) to 6 to time.Weekday.
+func StringToTimeWeekdayHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		if t != reflect.TypeOf(time.Sunday) {
+			return data, nil
+		}
+
+		// Convert it by parsing
+		d, ok := parseTimeName(reflect.ValueOf(data).String(), 0, 6, func(i int) string {
+			return time.Weekday(i).String()
+		})
+		if !ok {
+			return nil, &ParseError{
+				Expected: reflect.New(t).Elem(),
+				Value:    data,
+				Err:      errors.New("invalid weekday"),
+			}
+		}
+
+		return time.Weekday(d), nil
+	}
+}
+
+// parseTimeName parses s as a number from first to last, or as the name
+// of one of these numbers or its three letter abbreviation.
+func parseTimeName(s string, first, last int, name func(int) string) (int, bool) {
+	if i, err := strconv.Atoi(s); err == nil {
+		return i, i >= first && i <= last
+	}
+
+	for i := first; i <= last; i++ {
+		n := name(i)
+		if strings.EqualFold(s, n) || strings.EqualFold(s, n[:3]) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// StringToMailAddressHookFunc returns a DecodeHookFunc that converts RFC
+// 5322 addresses such as "Jane Doe <jane@example.com>" to mail.Address or
+// *mail.Address.
+func StringToMailAddressHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		if t != reflect.TypeOf(mail.Address{}) && t != reflect.TypeOf(&mail.Address{}) {
+			return data, nil
+		}
+
+		// Convert it by parsing
+		addr, err := mail.ParseAddress(reflect.ValueOf(data).String())
+		if err != nil {
+			return nil, wrapMailParseAddressError(err)
+		}
+
+		if t.Kind() == reflect.Ptr {
+			return addr, nil
+		}
+
+		return *addr, nil
+	}
+}
+
+// TimeDurationToStringHookFunc returns an encode hook that converts
+// time.Duration to strings, reversing StringToTimeDurationHookFunc.
+func TimeDurationToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(time.Duration(5)) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(time.Duration).String(), nil
+	}
+}
+
+// TimeLocationToStringHookFunc returns an encode hook that converts
+// *time.Location to its name, reversing StringToTimeLocationHookFunc.
+func TimeLocationToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(time.Local) || !acceptsString(t) {
+			return data, nil
+		}
+
+		loc := data.(*time.Location)
+		if loc == nil {
+			return data, nil
+		}
+
+		return loc.String(), nil
+	}
+}
+
+// URLToStringHookFunc returns an encode hook that converts *url.URL to
+// strings, reversing StringToURLHookFunc.
+func URLToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(&url.URL{}) || !acceptsString(t) {
+			return data, nil
+		}
+
+		u := data.(*url.URL)
+		if u == nil {
+			return data, nil
+		}
+
+		return u.String(), nil
+	}
+}
+
+// IPToStringHookFunc returns an encode hook that converts net.IP to
+// strings, reversing StringToIPHookFunc.
+func IPToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(net.IP{}) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(net.IP).String(), nil
+	}
+}
+
+// IPNetToStringHookFunc returns an encode hook that converts net.IPNet and
+// *net.IPNet to strings in CIDR notation, reversing StringToIPNetHookFunc.
+func IPNetToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if !acceptsString(t) {
+			return data, nil
+		}
+
+		switch ipNet := data.(type) {
+		case net.IPNet:
+			return ipNet.String(), nil
+		case *net.IPNet:
+			if ipNet != nil {
+				return ipNet.String(), nil
+			}
+		}
+
+		return data, nil
+	}
+}
+
+// TimeToStringHookFunc returns an encode hook that converts time.Time to
+// strings using the given layout, reversing StringToTimeHookFunc.
+func TimeToStringHookFunc(layout string) DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(time.Time{}) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(time.Time).Format(layout), nil
+	}
+}
+
+// NetIPAddrToStringHookFunc returns an encode hook that converts
+// netip.Addr to strings, reversing StringToNetIPAddrHookFunc.
+func NetIPAddrToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(netip.Addr{}) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(netip.Addr).String(), nil
+	}
+}
+
+// NetIPAddrPortToStringHookFunc returns an encode hook that converts
+// netip.AddrPort to strings, reversing StringToNetIPAddrPortHookFunc.
+func NetIPAddrPortToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(netip.AddrPort{}) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(netip.AddrPort).String(), nil
+	}
+}
+
+// NetIPPrefixToStringHookFunc returns an encode hook that converts
+// netip.Prefix to strings, reversing StringToNetIPPrefixHookFunc.
+func NetIPPrefixToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(netip.Prefix{}) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(netip.Prefix).String(), nil
+	}
+}
+
+// ByteSizeToStringHookFunc returns an encode hook that converts ByteSize
+// to strings such as "512MiB", reversing StringToByteSizeHookFunc.
+func ByteSizeToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(ByteSize(0)) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(ByteSize).String(), nil
+	}
+}
+
+// BytesToBase64HookFunc returns an encode hook that encodes []byte and
+// [N]byte to base64 strings using enc, reversing Base64ToBytesHookFunc.
+func BytesToBase64HookFunc(enc *base64.Encoding) DecodeHookFunc {
+	return base64BytesEncoding(enc).encodeHook()
+}
+
+// BytesToHexHookFunc returns an encode hook that encodes []byte and
+// [N]byte to hex strings, reversing HexToBytesHookFunc.
+func BytesToHexHookFunc() DecodeHookFunc {
+	return hexBytesEncoding.encodeHook()
+}
+
+// RegexpToStringHookFunc returns an encode hook that converts
+// *regexp.Regexp and regexp.Regexp to their source pattern, reversing
+// StringToRegexpHookFunc and StringToPOSIXRegexpHookFunc.
+func RegexpToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Value,
+		t reflect.Value,
+	) (any, error) {
+		if !acceptsString(t.Type()) {
+			return f.Interface(), nil
+		}
+
+		switch f.Type() {
+		case reflect.TypeOf(&regexp.Regexp{}):
+			if f.IsNil() {
+				return f.Interface(), nil
+			}
+
+			return f.Interface().(*regexp.Regexp).String(), nil
+		case reflect.TypeOf(regexp.Regexp{}):
+			return addressable(f).Addr().Interface().(*regexp.Regexp).String(), nil
+		}
+
+		return f.Interface(), nil
+	}
+}
+
+// FileModeToStringHookFunc returns an encode hook that converts
+// os.FileMode to octal strings such as "0644", reversing
+// StringToFileModeHookFunc. Only the permission, setuid, setgid and sticky
+// bits are encoded.
+func FileModeToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(os.FileMode(0)) || !acceptsString(t) {
+			return data, nil
+		}
+
+		mode := data.(os.FileMode)
+		u := uint32(mode.Perm())
+		if mode&os.ModeSetuid != 0 {
+			u |= 0o4000
+		}
+		if mode&os.ModeSetgid != 0 {
+			u |= 0o2000
+		}
+		if mode&os.ModeSticky != 0 {
+			u |= 0o1000
+		}
+
+		return fmt.Sprintf("%04o", u), nil
+	}
+}
+
+// TimeMonthToStringHookFunc returns an encode hook that converts
+// time.Month to its name, reversing StringToTimeMonthHookFunc.
+func TimeMonthToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(time.Month(1)) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(time.Month).String(), nil
+	}
+}
+
+// TimeWeekdayToStringHookFunc returns an encode hook that converts
+// time.Weekday to its name, reversing StringToTimeWeekdayHookFunc.
+func TimeWeekdayToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(time.Sunday) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(time.Weekday).String(), nil
+	}
+}
+
+// MailAddressToStringHookFunc returns an encode hook that converts
+// mail.Address and *mail.Address to RFC 5322 addresses, reversing
+// StringToMailAddressHookFunc.
+func MailAddressToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if !acceptsString(t) {
+			return data, nil
+		}
+
+		switch addr := data.(type) {
+		case mail.Address:
+			return addr.String(), nil
+		case *mail.Address:
+			if addr != nil {
+				return addr.String(), nil
+			}
+		}
+
+		return data, nil
+	}
+}
+
+// acceptsString reports whether an encode hook may store a string into a
+// value of type t.
+func acceptsString(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
+// HookPair groups a decode hook with the encode hook that reverses it, so
+// that values survive a round-trip through a map. See
+// DecoderConfig.AddHookPairs.
+type HookPair struct {
+	Decode DecodeHookFunc
+	Encode DecodeHookFunc
+}
+
+// AddHookPairs installs the decode and encode hooks of the given pairs.
+// The hooks are composed in order after any DecodeHook and EncodeHook
+// already set on the config.
+func (c *DecoderConfig) AddHookPairs(pairs ...HookPair) {
+	decodeHooks := make([]DecodeHookFunc, 0, len(pairs)+1)
+	encodeHooks := make([]DecodeHookFunc, 0, len(pairs)+1)
+	if c.DecodeHook != nil {
+		decodeHooks = append(decodeHooks, c.DecodeHook)
+	}
+	if c.EncodeHook != nil {
+		encodeHooks = append(encodeHooks, c.EncodeHook)
+	}
+
+	for _, p := range pairs {
+		if p.Decode != nil {
+			decodeHooks = append(decodeHooks, p.Decode)
+		}
+		if p.Encode != nil {
+			encodeHooks = append(encodeHooks, p.Encode)
+		}
+	}
+
+	if len(decodeHooks) > 0 {
+		c.DecodeHook = ComposeDecodeHookFunc(decodeHooks...)
+	}
+	if len(encodeHooks) > 0 {
+		c.EncodeHook = ComposeDecodeHookFunc(encodeHooks...)
+	}
+}
+
+// TimeDurationHookPair returns the HookPair for time.Duration.
+func TimeDurationHookPair() HookPair {
+	return HookPair{StringToTimeDurationHookFunc(), TimeDurationToStringHookFunc()}
+}
+
+// TimeLocationHookPair returns the HookPair for *time.Location.
+func TimeLocationHookPair() HookPair {
+	return HookPair{StringToTimeLocationHookFunc(), TimeLocationToStringHookFunc()}
+}
+
+// URLHookPair returns the HookPair for *url.URL.
+func URLHookPair() HookPair {
+	return HookPair{StringToURLHookFunc(), URLToStringHookFunc()}
+}
+
+// IPHookPair returns the HookPair for net.IP.
+func IPHookPair() HookPair {
+	return HookPair{StringToIPHookFunc(), IPToStringHookFunc()}
+}
+
+// IPNetHookPair returns the HookPair for net.IPNet.
+func IPNetHookPair() HookPair {
+	return HookPair{StringToIPNetHookFunc(), IPNetToStringHookFunc()}
+}
+
+// TimeHookPair returns the HookPair for time.Time using the given layout.
+func TimeHookPair(layout string) HookPair {
+	return HookPair{StringToTimeHookFunc(layout), TimeToStringHookFunc(layout)}
+}
+
+// NetIPAddrHookPair returns the HookPair for netip.Addr.
+func NetIPAddrHookPair() HookPair {
+	return HookPair{StringToNetIPAddrHookFunc(), NetIPAddrToStringHookFunc()}
+}
+
+// NetIPAddrPortHookPair returns the HookPair for netip.AddrPort.
+func NetIPAddrPortHookPair() HookPair {
+	return HookPair{StringToNetIPAddrPortHookFunc(), NetIPAddrPortToStringHookFunc()}
+}
+
+// NetIPPrefixHookPair returns the HookPair for netip.Prefix.
+func NetIPPrefixHookPair() HookPair {
+	return HookPair{StringToNetIPPrefixHookFunc(), NetIPPrefixToStringHookFunc()}
+}
+
+// ByteSizeHookPair returns the HookPair for ByteSize.
+func ByteSizeHookPair() HookPair {
+	return HookPair{StringToByteSizeHookFunc(), ByteSizeToStringHookFunc()}
+}
+
+// Base64HookPair returns the HookPair for []byte and [N]byte encoded as
+// base64 with enc.
+func Base64HookPair(enc *base64.Encoding) HookPair {
+	return HookPair{Base64ToBytesHookFunc(enc), BytesToBase64HookFunc(enc)}
+}
+
+// HexHookPair returns the HookPair for []byte and [N]byte encoded as hex.
+func HexHookPair() HookPair {
+	return HookPair{HexToBytesHookFunc(), BytesToHexHookFunc()}
+}
+
+// RegexpHookPair returns the HookPair for *regexp.Regexp and
+// regexp.Regexp.
+func RegexpHookPair() HookPair {
+	return HookPair{StringToRegexpHookFunc(), RegexpToStringHookFunc()}
+}
+
+// FileModeHookPair returns the HookPair for os.FileMode.
+func FileModeHookPair() HookPair {
+	return HookPair{StringToFileModeHookFunc(), FileModeToStringHookFunc()}
+}
+
+// TimeMonthHookPair returns the HookPair for time.Month.
+func TimeMonthHookPair() HookPair {
+	return HookPair{StringToTimeMonthHookFunc(), TimeMonthToStringHookFunc()}
+}
+
+// TimeWeekdayHookPair returns the HookPair for time.Weekday.
+func TimeWeekdayHookPair() HookPair {
+	return HookPair{StringToTimeWeekdayHookFunc(), TimeWeekdayToStringHookFunc()}
+}
+
+// MailAddressHookPair returns the HookPair for mail.Address and
+// *mail.Address.
+func MailAddressHookPair() HookPair {
+	return HookPair{StringToMailAddressHookFunc(), MailAddressToStringHookFunc()}
+}
+
 // StringToBasicTypeHookFunc returns a DecodeHookFunc that converts
 // strings to basic types.
 // int8, uint8, int16, uint16, int32, uint32, int64, uint64, int, uint, float32, float64, bool, byte, rune, complex64, complex128
@@ -0,0 +1,53 @@
+//go:build go1.21
+
+package mapstructure
+
+import (
+	"log/slog"
+	"reflect"
+)
+
+// StringToSlogLevelHookFunc returns a DecodeHookFunc that converts level
+// names such as "debug", "INFO" or "warn+2" to slog.Level, as parsed by
+// slog.Level.UnmarshalText.
+func StringToSlogLevelHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		if t != reflect.TypeOf(slog.LevelInfo) {
+			return data, nil
+		}
+
+		// Convert it by parsing
+		var level slog.Level
+		err := level.UnmarshalText([]byte(reflect.ValueOf(data).String()))
+
+		return level, wrapSlogLevelError(err)
+	}
+}
+
+// SlogLevelToStringHookFunc returns an encode hook that converts
+// slog.Level to its name, reversing StringToSlogLevelHookFunc.
+func SlogLevelToStringHookFunc() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f != reflect.TypeOf(slog.LevelInfo) || !acceptsString(t) {
+			return data, nil
+		}
+
+		return data.(slog.Level).String(), nil
+	}
+}
+
+// SlogLevelHookPair returns the HookPair for slog.Level.
+func SlogLevelHookPair() HookPair {
+	return HookPair{StringToSlogLevelHookFunc(), SlogLevelToStringHookFunc()}
+}
@@ -0,0 +1,61 @@
+//go:build go1.21
+
+package mapstructure
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestStringToSlogLevelHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[string, slog.Level]{
+		fn: StringToSlogLevelHookFunc(),
+		ok: []decodeHookTestCase[string, slog.Level]{
+			{"debug", slog.LevelDebug},
+			{"INFO", slog.LevelInfo},
+			{"Warn", slog.LevelWarn},
+			{"error", slog.LevelError},
+			{"warn+2", slog.LevelWarn + 2},
+			{"info-4", slog.LevelDebug},
+		},
+		fail: []decodeHookFailureTestCase[string, slog.Level]{
+			{"verbose"},
+			{"info+x"},
+			{""},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestStringToSlogLevelHookFunc_Error(t *testing.T) {
+	var result struct {
+		Level slog.Level
+	}
+
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: StringToSlogLevelHookFunc(),
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{"level": "verbose"})
+	if err == nil || !strings.Contains(err.Error(), "'Level' slog: invalid level") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSlogLevelToStringHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[slog.Level, string]{
+		fn: SlogLevelToStringHookFunc(),
+		ok: []decodeHookTestCase[slog.Level, string]{
+			{slog.LevelDebug, "DEBUG"},
+			{slog.LevelWarn + 2, "WARN+2"},
+		},
+	}
+
+	suite.Run(t)
+}
@@ -7,9 +7,15 @@ import (
 	"math"
 	"math/big"
 	"net"
+	"net/mail"
 	"net/netip"
 	"net/url"
+	"os"
 	"reflect"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -266,11 +272,109 @@ func TestOrComposeDecodeHookFunc_err(t *testing.T) {
 	if err == nil {
 		t.Fatalf("bad: should return an error")
 	}
-	if err.Error() != "f1 error\nf2 error\n" {
+	if err.Error() != "hook 0: f1 error; hook 1: f2 error" {
 		t.Fatalf("bad: %s", err)
 	}
 }
 
+func TestOrComposeDecodeHookFunc_errTypes(t *testing.T) {
+	f := OrComposeDecodeHookFunc(
+		StringToTimeDurationHookFunc(),
+		NamedDecodeHookFunc("extended", ExtendedDurationHookFunc(DurationDays, 0)),
+	)
+
+	_, err := DecodeHookExec(f, reflect.ValueOf("soon"), reflect.ValueOf(time.Duration(0)))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.HasPrefix(err.Error(), "hook 0: time: invalid duration; hook \"extended\": ") {
+		t.Fatalf("bad: %s", err)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected ParseError, got %T", err)
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Fatalf("expected 2 joined errors, got %#v", err)
+	}
+
+	for i, err := range joined.Unwrap() {
+		herr, ok := err.(*HookError)
+		if !ok || herr.Index != i {
+			t.Fatalf("expected HookError for hook %d, got %#v", i, err)
+		}
+	}
+
+	if herr := joined.Unwrap()[1].(*HookError); herr.Name != "extended" || herr.Err != perr {
+		t.Fatalf("bad: %#v", herr)
+	}
+}
+
+func TestOrComposeDecodeHookFunc_errDecode(t *testing.T) {
+	var result time.Duration
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: OrComposeDecodeHookFunc(
+			StringToTimeDurationHookFunc(),
+			ExtendedDurationHookFunc(DurationDays, 0),
+		),
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The errors of the hooks are those of a single value, reported on a
+	// single line.
+	err = decoder.Decode("soon")
+	if err == nil || !strings.HasPrefix(err.Error(), "'' hook 0: time: invalid duration; hook 1: ") || strings.Contains(err.Error(), "\n") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNamedDecodeHookFunc(t *testing.T) {
+	type Config struct {
+		Timeout time.Duration
+		Labels  map[string]int
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: ComposeDecodeHookFunc(
+			NamedDecodeHookFunc("labels", StringToMapHookFunc(",", "=")),
+			NamedDecodeHookFunc("duration", StringToTimeDurationHookFunc()),
+		),
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{
+		"timeout": "soon",
+		"labels":  "a=1,a=2",
+	})
+	if err == nil ||
+		!strings.Contains(err.Error(), `'Timeout' hook "duration": time: invalid duration`) ||
+		!strings.Contains(err.Error(), `'Labels[a]' hook "labels": duplicate key`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var herr *HookError
+	if !errors.As(err, &herr) || herr.Index != -1 {
+		t.Fatalf("expected HookError, got %#v", herr)
+	}
+
+	if err := decoder.Decode(map[string]any{"timeout": "5s"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Timeout != 5*time.Second {
+		t.Fatalf("bad: %#v", result)
+	}
+}
+
 func TestComposeDecodeHookFunc_safe_nofuncs(t *testing.T) {
 	f := ComposeDecodeHookFunc()
 	type myStruct2 struct {
@@ -303,6 +407,28 @@ func TestComposeDecodeHookFunc_safe_nofuncs(t *testing.T) {
 	}
 }
 
+func TestComposeDecodeHookFunc_type(t *testing.T) {
+	cases := []struct {
+		hook     DecodeHookFunc
+		expected any
+	}{
+		{ComposeDecodeHookFunc(), "5s"},
+		{OrComposeDecodeHookFunc(StringToTimeDurationHookFunc()), 5 * time.Second},
+	}
+
+	for _, tc := range cases {
+		hook, ok := tc.hook.(DecodeHookFuncPath)
+		if !ok {
+			t.Fatalf("expected DecodeHookFuncPath, got %T", tc.hook)
+		}
+
+		out, err := hook("Timeout", reflect.ValueOf("5s"), reflect.ValueOf(time.Duration(0)))
+		if err != nil || out != tc.expected {
+			t.Fatalf("unexpected result %#v, %v", out, err)
+		}
+	}
+}
+
 func TestComposeDecodeHookFunc_ReflectValueHook(t *testing.T) {
 	reflectValueHook := func(
 		f reflect.Kind,
@@ -2123,3 +2249,1277 @@ func TestErrorLeakageDecodeHook(t *testing.T) {
 		}
 	}
 }
+
+func TestTimeDurationToStringHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[time.Duration, string]{
+		fn: TimeDurationToStringHookFunc(),
+		ok: []decodeHookTestCase[time.Duration, string]{
+			{5 * time.Second, "5s"},
+			{time.Hour + 30*time.Minute, "1h30m0s"},
+			{0, "0s"},
+			{-100 * time.Millisecond, "-100ms"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestTimeLocationToStringHookFunc(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	suite := decodeHookTestSuite[*time.Location, string]{
+		fn: TimeLocationToStringHookFunc(),
+		ok: []decodeHookTestCase[*time.Location, string]{
+			{time.UTC, "UTC"},
+			{newYork, "America/New_York"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestURLToStringHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[*url.URL, string]{
+		fn: URLToStringHookFunc(),
+		ok: []decodeHookTestCase[*url.URL, string]{
+			{&url.URL{Scheme: "https", Host: "example.com", Path: "/a"}, "https://example.com/a"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestIPToStringHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[net.IP, string]{
+		fn: IPToStringHookFunc(),
+		ok: []decodeHookTestCase[net.IP, string]{
+			{net.IPv4(192, 168, 1, 1), "192.168.1.1"},
+			{net.ParseIP("::1"), "::1"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestIPNetToStringHookFunc(t *testing.T) {
+	_, ipNet, _ := net.ParseCIDR("10.0.0.0/8")
+
+	suite := decodeHookTestSuite[net.IPNet, string]{
+		fn: IPNetToStringHookFunc(),
+		ok: []decodeHookTestCase[net.IPNet, string]{
+			{*ipNet, "10.0.0.0/8"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestTimeToStringHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[time.Time, string]{
+		fn: TimeToStringHookFunc(time.RFC3339),
+		ok: []decodeHookTestCase[time.Time, string]{
+			{time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "2024-01-02T03:04:05Z"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestNetIPToStringHookFuncs(t *testing.T) {
+	t.Run("Addr", decodeHookTestSuite[netip.Addr, string]{
+		fn: NetIPAddrToStringHookFunc(),
+		ok: []decodeHookTestCase[netip.Addr, string]{
+			{netip.MustParseAddr("10.0.0.1"), "10.0.0.1"},
+		},
+	}.Run)
+
+	t.Run("AddrPort", decodeHookTestSuite[netip.AddrPort, string]{
+		fn: NetIPAddrPortToStringHookFunc(),
+		ok: []decodeHookTestCase[netip.AddrPort, string]{
+			{netip.MustParseAddrPort("10.0.0.1:80"), "10.0.0.1:80"},
+		},
+	}.Run)
+
+	t.Run("Prefix", decodeHookTestSuite[netip.Prefix, string]{
+		fn: NetIPPrefixToStringHookFunc(),
+		ok: []decodeHookTestCase[netip.Prefix, string]{
+			{netip.MustParsePrefix("10.0.0.0/8"), "10.0.0.0/8"},
+		},
+	}.Run)
+}
+
+func TestDecoderConfig_AddHookPairs(t *testing.T) {
+	type Config struct {
+		Timeout  time.Duration
+		Retries  []time.Duration
+		Endpoint *url.URL
+		Addr     netip.Addr
+		Network  netip.Prefix
+	}
+
+	input := Config{
+		Timeout:  5 * time.Second,
+		Retries:  []time.Duration{time.Second, time.Minute},
+		Endpoint: &url.URL{Scheme: "https", Host: "example.com"},
+		Addr:     netip.MustParseAddr("10.0.0.1"),
+		Network:  netip.MustParsePrefix("10.0.0.0/8"),
+	}
+
+	var encoded map[string]any
+	encodeConfig := &DecoderConfig{
+		RecursiveEncode: true,
+		Result:          &encoded,
+	}
+	encodeConfig.AddHookPairs(
+		TimeDurationHookPair(),
+		URLHookPair(),
+		NetIPAddrHookPair(),
+		NetIPPrefixHookPair(),
+	)
+
+	decoder, err := NewDecoder(encodeConfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]any{
+		"Timeout":  "5s",
+		"Retries":  []any{"1s", "1m0s"},
+		"Endpoint": "https://example.com",
+		"Addr":     "10.0.0.1",
+		"Network":  "10.0.0.0/8",
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", expected, encoded)
+	}
+
+	var result Config
+	decodeConfig := &DecoderConfig{Result: &result}
+	decodeConfig.AddHookPairs(
+		TimeDurationHookPair(),
+		URLHookPair(),
+		NetIPAddrHookPair(),
+		NetIPPrefixHookPair(),
+	)
+
+	decoder, err = NewDecoder(decodeConfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(encoded); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(result, input) {
+		t.Fatalf("expected %#v, got %#v", input, result)
+	}
+}
+
+func TestStringToByteSizeHookFunc(t *testing.T) {
+	t.Run("ByteSize", decodeHookTestSuite[string, ByteSize]{
+		fn: StringToByteSizeHookFunc(),
+		ok: []decodeHookTestCase[string, ByteSize]{
+			{"512MiB", 512 * Mebibyte},
+			{"10GB", 10 * Gigabyte},
+			{"1.5k", 1500},
+		},
+		fail: []decodeHookFailureTestCase[string, ByteSize]{
+			{"-1k"},
+			{"1.5"},
+			{"10XB"},
+		},
+	}.Run)
+
+	actual, err := DecodeHookExec(StringToByteSizeHookFunc(), reflect.ValueOf("1k"), reflect.ValueOf(0))
+	if err != nil || actual != "1k" {
+		t.Fatalf("expected int target to be left unchanged, got %v, %v", actual, err)
+	}
+}
+
+func TestStringToIntByteSizeHookFunc(t *testing.T) {
+	t.Run("Int32", decodeHookTestSuite[string, int32]{
+		fn: StringToIntByteSizeHookFunc(),
+		ok: []decodeHookTestCase[string, int32]{
+			{"1GiB", 1 << 30},
+			{"-1k", -1000},
+			{"2147483647", 1<<31 - 1},
+		},
+		fail: []decodeHookFailureTestCase[string, int32]{
+			{"2GiB"},
+			{"-3GB"},
+		},
+	}.Run)
+
+	t.Run("Uint8", decodeHookTestSuite[string, uint8]{
+		fn: StringToIntByteSizeHookFunc(),
+		ok: []decodeHookTestCase[string, uint8]{
+			{"255", 255},
+		},
+		fail: []decodeHookFailureTestCase[string, uint8]{
+			{"256"},
+			{"1k"},
+		},
+	}.Run)
+}
+
+func TestStringToIntByteSizeHookFunc_Error(t *testing.T) {
+	type Config struct {
+		Buffer int16
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: StringToIntByteSizeHookFunc(),
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{"buffer": "64KiB"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || !errors.Is(err, strconv.ErrRange) {
+		t.Fatalf("expected range ParseError, got %v", err)
+	}
+
+	expected := "'Buffer' cannot parse value as 'int16': value out of range"
+	if !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected error containing %q, got %q", expected, err)
+	}
+}
+
+func TestByteSizeHookPair_OtherPairs(t *testing.T) {
+	type Config struct {
+		Size    ByteSize
+		Timeout time.Duration
+		Month   time.Month
+		Mode    os.FileMode
+	}
+
+	var result Config
+	config := &DecoderConfig{Result: &result}
+	config.AddHookPairs(ByteSizeHookPair(), TimeDurationHookPair(), TimeMonthHookPair(), FileModeHookPair())
+
+	decoder, err := NewDecoder(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{"size": "1KiB", "timeout": "5s", "month": "jan", "mode": "0644"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{Size: Kibibyte, Timeout: 5 * time.Second, Month: time.January, Mode: 0o644}
+	if result != expected {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+
+	_, err = DecodeHookExec(StringToIntByteSizeHookFunc(), reflect.ValueOf("5s"), reflect.ValueOf(time.Duration(0)))
+	if err != nil {
+		t.Fatalf("expected time.Duration to be left unchanged, got %s", err)
+	}
+}
+
+func TestByteSizeToStringHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[ByteSize, string]{
+		fn: ByteSizeToStringHookFunc(),
+		ok: []decodeHookTestCase[ByteSize, string]{
+			{512 * Mebibyte, "512MiB"},
+			{10 * Gigabyte, "10GB"},
+			{1500, "1500B"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestExtendedDurationHookFunc(t *testing.T) {
+	all := DurationDays | DurationISO8601 | DurationNumbers
+	day := 24 * time.Hour
+
+	t.Run("String", decodeHookTestSuite[string, time.Duration]{
+		fn: ExtendedDurationHookFunc(all, 0),
+		ok: []decodeHookTestCase[string, time.Duration]{
+			{"5s", 5 * time.Second},
+			{"1h30m", 90 * time.Minute},
+			{"7d", 7 * day},
+			{"1.5d", 36 * time.Hour},
+			{"1w2d12h", 9*day + 12*time.Hour},
+			{"-2d", -2 * day},
+			{"0", 0},
+			{"PT15M", 15 * time.Minute},
+			{"P1DT12H", 36 * time.Hour},
+			{"P2W", 14 * day},
+			{"PT0.5S", 500 * time.Millisecond},
+			{"-PT1M", -time.Minute},
+			{"30", 30 * time.Second},
+			{"1.5", 1500 * time.Millisecond},
+		},
+		fail: []decodeHookFailureTestCase[string, time.Duration]{
+			{""},
+			{"d"},
+			{"7x"},
+			{"P1Y"},
+			{"P1M"},
+			{"PT"},
+			{"P"},
+			{"P1DT"},
+			{"100000000000000d"},
+			{"1e300"},
+		},
+	}.Run)
+
+	t.Run("Int", decodeHookTestSuite[int, time.Duration]{
+		fn: ExtendedDurationHookFunc(DurationNumbers, time.Millisecond),
+		ok: []decodeHookTestCase[int, time.Duration]{
+			{250, 250 * time.Millisecond},
+			{-1, -time.Millisecond},
+		},
+	}.Run)
+
+	t.Run("Float", decodeHookTestSuite[float64, time.Duration]{
+		fn: ExtendedDurationHookFunc(DurationNumbers, day),
+		ok: []decodeHookTestCase[float64, time.Duration]{
+			{0.5, 12 * time.Hour},
+		},
+		fail: []decodeHookFailureTestCase[float64, time.Duration]{
+			{1e300},
+		},
+	}.Run)
+
+	t.Run("JSONNumber", decodeHookTestSuite[json.Number, time.Duration]{
+		fn: ExtendedDurationHookFunc(DurationNumbers, time.Minute),
+		ok: []decodeHookTestCase[json.Number, time.Duration]{
+			{"15", 15 * time.Minute},
+			{"0.5", 30 * time.Second},
+		},
+		fail: []decodeHookFailureTestCase[json.Number, time.Duration]{
+			{"abc"},
+		},
+	}.Run)
+
+	t.Run("DisabledForms", decodeHookTestSuite[string, time.Duration]{
+		fn: ExtendedDurationHookFunc(0, 0),
+		ok: []decodeHookTestCase[string, time.Duration]{
+			{"5s", 5 * time.Second},
+		},
+		fail: []decodeHookFailureTestCase[string, time.Duration]{
+			{"7d"},
+			{"PT15M"},
+			{"30"},
+		},
+	}.Run)
+}
+
+func TestExtendedDurationHookFunc_Decode(t *testing.T) {
+	type Config struct {
+		Retention time.Duration
+		TTL       time.Duration
+		Timeout   time.Duration
+	}
+
+	input := map[string]any{
+		"retention": "30d",
+		"ttl":       3600,
+		"timeout":   "PT1M30S",
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: ExtendedDurationHookFunc(DurationDays|DurationISO8601|DurationNumbers, time.Second),
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{
+		Retention: 30 * 24 * time.Hour,
+		TTL:       time.Hour,
+		Timeout:   90 * time.Second,
+	}
+	if result != expected {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+
+	err = decoder.Decode(map[string]any{"ttl": "soon"})
+	if err == nil || !strings.Contains(err.Error(), "'TTL' cannot parse value as 'time.Duration'") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTimeHookFunc(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	config := TimeHookConfig{
+		Layouts:  []string{time.RFC3339Nano, "2006-01-02 15:04:05", "02/01/2006"},
+		Epoch:    time.Millisecond,
+		Location: newYork,
+	}
+
+	t.Run("String", decodeHookTestSuite[string, time.Time]{
+		fn: TimeHookFunc(config),
+		ok: []decodeHookTestCase[string, time.Time]{
+			{"2024-01-02T03:04:05.5Z", time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC)},
+			{"2024-01-02 03:04:05", time.Date(2024, 1, 2, 3, 4, 5, 0, newYork)},
+			{"02/01/2024", time.Date(2024, 1, 2, 0, 0, 0, 0, newYork)},
+			{"1704164645000", time.Unix(1704164645, 0).In(newYork)},
+		},
+		fail: []decodeHookFailureTestCase[string, time.Time]{
+			{"yesterday"},
+			{"2024-13-45"},
+		},
+	}.Run)
+
+	t.Run("Int", decodeHookTestSuite[int64, time.Time]{
+		fn: TimeHookFunc(config),
+		ok: []decodeHookTestCase[int64, time.Time]{
+			{1704164645123, time.Unix(1704164645, 123e6).In(newYork)},
+			{-1, time.Unix(0, -1e6).In(newYork)},
+		},
+	}.Run)
+
+	t.Run("Float", decodeHookTestSuite[float64, time.Time]{
+		fn: TimeHookFunc(TimeHookConfig{Epoch: time.Second}),
+		ok: []decodeHookTestCase[float64, time.Time]{
+			{1704164645.25, time.Unix(1704164645, 25e7).UTC()},
+		},
+		fail: []decodeHookFailureTestCase[float64, time.Time]{
+			{1e300},
+		},
+	}.Run)
+
+	t.Run("JSONNumber", decodeHookTestSuite[json.Number, time.Time]{
+		fn: TimeHookFunc(TimeHookConfig{Epoch: time.Nanosecond}),
+		ok: []decodeHookTestCase[json.Number, time.Time]{
+			{"1704164645000000001", time.Unix(1704164645, 1).UTC()},
+		},
+		fail: []decodeHookFailureTestCase[json.Number, time.Time]{
+			{"abc"},
+		},
+	}.Run)
+
+	t.Run("NoEpoch", decodeHookTestSuite[string, time.Time]{
+		fn: TimeHookFunc(TimeHookConfig{Layouts: []string{"2006-01-02"}}),
+		ok: []decodeHookTestCase[string, time.Time]{
+			{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		},
+		fail: []decodeHookFailureTestCase[string, time.Time]{
+			{"1704164645"},
+		},
+	}.Run)
+}
+
+func TestTimeHookFunc_Error(t *testing.T) {
+	type Config struct {
+		Created time.Time
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: TimeHookFunc(TimeHookConfig{
+			Layouts: []string{time.RFC3339, "2006-01-02"},
+		}),
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{"created": "yesterday"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	expected := `'Created' cannot parse value as 'time.Time': parsing time: does not match any of the layouts "2006-01-02T15:04:05Z07:00", "2006-01-02"`
+	if !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected error containing %q, got %q", expected, err)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Value != "yesterday" {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+
+	// The errors of the layouts are those of a single value.
+	var created time.Time
+	decoder, err = NewDecoder(&DecoderConfig{
+		DecodeHook: TimeHookFunc(TimeHookConfig{
+			Layouts: []string{time.RFC3339, "2006-01-02"},
+		}),
+		Result: &created,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode("yesterday")
+	if err == nil || strings.Contains(err.Error(), "\n") {
+		t.Fatalf("expected a single line error, got %q", err)
+	}
+}
+
+func TestStringToRegexpHookFunc(t *testing.T) {
+	type Config struct {
+		Route  *regexp.Regexp
+		Filter regexp.Regexp
+		Routes []*regexp.Regexp
+	}
+
+	input := map[string]any{
+		"route":  "^/api/(v[0-9]+)/",
+		"filter": "a+b",
+		"routes": []any{"x", "y$"},
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: StringToRegexpHookFunc(),
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !result.Route.MatchString("/api/v2/users") || !result.Filter.MatchString("xaab") {
+		t.Fatalf("unexpected result: %v, %v", result.Route, &result.Filter)
+	}
+	if len(result.Routes) != 2 || result.Routes[1].String() != "y$" {
+		t.Fatalf("unexpected routes: %v", result.Routes)
+	}
+
+	err = decoder.Decode(map[string]any{"routes": []any{"ok", "a("}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	expected := "'Routes[1]' cannot parse value as '*regexp.Regexp': error parsing regexp: missing closing )"
+	if !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected error containing %q, got %q", expected, err)
+	}
+
+	var perr *ParseError
+	var serr *syntax.Error
+	if !errors.As(err, &perr) || !errors.As(err, &serr) || serr.Expr != "a(" {
+		t.Fatalf("expected ParseError wrapping syntax.Error, got %v", err)
+	}
+}
+
+func TestStringToPOSIXRegexpHookFunc(t *testing.T) {
+	var result struct {
+		Pattern *regexp.Regexp
+	}
+
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: StringToPOSIXRegexpHookFunc(),
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(map[string]any{"pattern": "a+|a+b"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// POSIX regular expressions prefer the leftmost-longest match.
+	if m := result.Pattern.FindString("aab"); m != "aab" {
+		t.Fatalf("expected leftmost-longest match, got %q", m)
+	}
+
+	// Perl syntax such as \d is not part of POSIX ERE.
+	if err := decoder.Decode(map[string]any{"pattern": `\d`}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestRegexpToStringHookFunc(t *testing.T) {
+	type Config struct {
+		Route  *regexp.Regexp
+		Filter regexp.Regexp
+		Unset  *regexp.Regexp
+	}
+
+	input := Config{
+		Route:  regexp.MustCompile("^/api/"),
+		Filter: *regexp.MustCompile("a+b"),
+	}
+
+	var encoded map[string]any
+	config := &DecoderConfig{Result: &encoded}
+	config.AddHookPairs(RegexpHookPair())
+
+	decoder, err := NewDecoder(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]any{
+		"Route":  "^/api/",
+		"Filter": "a+b",
+		"Unset":  (*regexp.Regexp)(nil),
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, encoded)
+	}
+}
+
+func TestExpandEnvHookFunc(t *testing.T) {
+	env := map[string]string{
+		"HOST":  "db.local",
+		"PORT":  "5432",
+		"EMPTY": "",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	suite := decodeHookTestSuite[string, string]{
+		fn: ExpandEnvHookFunc(lookup),
+		ok: []decodeHookTestCase[string, string]{
+			{"plain", "plain"},
+			{"${HOST}:${PORT}", "db.local:5432"},
+			{"${MISSING}", ""},
+			{"${MISSING:-fallback}", "fallback"},
+			{"${EMPTY:-fallback}", "fallback"},
+			{"${HOST:-fallback}", "db.local"},
+			{"${MISSING:-}", ""},
+			{"${HOST:?required}", "db.local"},
+			{"pa$$word $HOST", "pa$$word $HOST"},
+			{"$${HOST}", "${HOST}"},
+			{"$$${HOST}", "$${HOST}"},
+			{"$", "$"},
+		},
+		fail: []decodeHookFailureTestCase[string, string]{
+			{"${HOST"},
+			{"${}"},
+			{"${HOST:=x}"},
+			{"${MISSING:?}"},
+			{"${EMPTY:?must be set}"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestExpandEnvHookFunc_Decode(t *testing.T) {
+	type Database struct {
+		Host     string
+		Password string
+		Timeout  time.Duration
+	}
+
+	type Config struct {
+		Database Database
+	}
+
+	env := map[string]string{"DB_HOST": "db.local", "DB_TIMEOUT": "5s"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: ComposeDecodeHookFunc(
+			ExpandEnvHookFunc(lookup),
+			StringToTimeDurationHookFunc(),
+		),
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	input := map[string]any{
+		"database": map[string]any{
+			"host":     "${DB_HOST}",
+			"password": "${DB_PASSWORD:-changeme}",
+			"timeout":  "${DB_TIMEOUT}",
+		},
+	}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{Database{Host: "db.local", Password: "changeme", Timeout: 5 * time.Second}}
+	if result != expected {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+
+	input = map[string]any{
+		"database": map[string]any{
+			"password": "${DB_PASSWORD:?is required}",
+		},
+	}
+	err = decoder.Decode(input)
+	if err == nil || !strings.Contains(err.Error(), "'Database.Password' environment variable DB_PASSWORD: is required") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStringToFileModeHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[string, os.FileMode]{
+		fn: StringToFileModeHookFunc(),
+		ok: []decodeHookTestCase[string, os.FileMode]{
+			{"0644", 0o644},
+			{"644", 0o644},
+			{"0o755", 0o755},
+			{"0", 0},
+			{"4755", os.ModeSetuid | 0o755},
+			{"2750", os.ModeSetgid | 0o750},
+			{"1777", os.ModeSticky | 0o777},
+		},
+		fail: []decodeHookFailureTestCase[string, os.FileMode]{
+			{"0999"},
+			{"rw-r--r--"},
+			{"17777"},
+			{""},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestStringToTimeMonthHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[string, time.Month]{
+		fn: StringToTimeMonthHookFunc(),
+		ok: []decodeHookTestCase[string, time.Month]{
+			{"January", time.January},
+			{"march", time.March},
+			{"DEC", time.December},
+			{"9", time.September},
+		},
+		fail: []decodeHookFailureTestCase[string, time.Month]{
+			{"0"},
+			{"13"},
+			{"Janu"},
+			{"ja"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestStringToTimeWeekdayHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[string, time.Weekday]{
+		fn: StringToTimeWeekdayHookFunc(),
+		ok: []decodeHookTestCase[string, time.Weekday]{
+			{"Sunday", time.Sunday},
+			{"mon", time.Monday},
+			{"SATURDAY", time.Saturday},
+			{"0", time.Sunday},
+			{"6", time.Saturday},
+		},
+		fail: []decodeHookFailureTestCase[string, time.Weekday]{
+			{"7"},
+			{"-1"},
+			{"weekend"},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestStringToTimeMonthHookFunc_Error(t *testing.T) {
+	cases := []struct {
+		hook DecodeHookFunc
+		to   any
+		err  string
+	}{
+		{StringToTimeMonthHookFunc(), time.Month(0), "cannot parse value as 'time.Month': invalid month"},
+		{StringToTimeWeekdayHookFunc(), time.Weekday(0), "cannot parse value as 'time.Weekday': invalid weekday"},
+	}
+
+	for _, tc := range cases {
+		_, err := DecodeHookExec(tc.hook, reflect.ValueOf("never"), reflect.ValueOf(tc.to))
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("expected error %q, got %v", tc.err, err)
+		}
+
+		var perr *ParseError
+		if !errors.As(err, &perr) || perr.Value != "never" {
+			t.Errorf("expected ParseError, got %#v", err)
+		}
+	}
+}
+
+func TestStringToMailAddressHookFunc(t *testing.T) {
+	t.Run("Value", decodeHookTestSuite[string, mail.Address]{
+		fn: StringToMailAddressHookFunc(),
+		ok: []decodeHookTestCase[string, mail.Address]{
+			{"Jane Doe <jane@example.com>", mail.Address{Name: "Jane Doe", Address: "jane@example.com"}},
+			{"jane@example.com", mail.Address{Address: "jane@example.com"}},
+		},
+		fail: []decodeHookFailureTestCase[string, mail.Address]{
+			{"jane"},
+			{"Jane <jane@example.com"},
+		},
+	}.Run)
+
+	t.Run("Pointer", decodeHookTestSuite[string, *mail.Address]{
+		fn: StringToMailAddressHookFunc(),
+		ok: []decodeHookTestCase[string, *mail.Address]{
+			{"<jane@example.com>", &mail.Address{Address: "jane@example.com"}},
+		},
+		fail: []decodeHookFailureTestCase[string, *mail.Address]{
+			{"@example.com"},
+		},
+	}.Run)
+}
+
+func TestStdlibHookPairs(t *testing.T) {
+	type Config struct {
+		Mode    os.FileMode
+		Month   time.Month
+		Day     time.Weekday
+		Owner   mail.Address
+		Contact *mail.Address
+	}
+
+	input := Config{
+		Mode:    0o640,
+		Month:   time.February,
+		Day:     time.Friday,
+		Owner:   mail.Address{Name: "Jane Doe", Address: "jane@example.com"},
+		Contact: &mail.Address{Address: "ops@example.com"},
+	}
+
+	pairs := []HookPair{FileModeHookPair(), TimeMonthHookPair(), TimeWeekdayHookPair(), MailAddressHookPair()}
+
+	var encoded map[string]any
+	config := &DecoderConfig{Result: &encoded}
+	config.AddHookPairs(pairs...)
+
+	decoder, err := NewDecoder(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]any{
+		"Mode":    "0640",
+		"Month":   "February",
+		"Day":     "Friday",
+		"Owner":   `"Jane Doe" <jane@example.com>`,
+		"Contact": "<ops@example.com>",
+	}
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, encoded)
+	}
+
+	var result Config
+	config = &DecoderConfig{Result: &result}
+	config.AddHookPairs(pairs...)
+
+	decoder, err = NewDecoder(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(encoded); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(result, input) {
+		t.Fatalf("expected %#v, got %#v", input, result)
+	}
+}
+
+func TestStringToMapHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[string, map[string]string]{
+		fn: StringToMapHookFunc(",", "="),
+		ok: []decodeHookTestCase[string, map[string]string]{
+			{"", map[string]string{}},
+			{"team=core,env=prod", map[string]string{"team": "core", "env": "prod"}},
+			{" team = core , env=prod,", map[string]string{"team": "core", "env": "prod"}},
+			{`msg="a,b=c",empty=`, map[string]string{"msg": "a,b=c", "empty": ""}},
+			{`"key with spaces"=" value "`, map[string]string{"key with spaces": " value "}},
+			{`path=a\,b,quote=\"x\"`, map[string]string{"path": "a,b", "quote": `"x"`}},
+			{"expr=a=b", map[string]string{"expr": "a=b"}},
+		},
+		fail: []decodeHookFailureTestCase[string, map[string]string]{
+			{"team"},
+			{"=core"},
+			{`msg="unterminated`},
+			{"a=1,a=2"},
+		},
+	}
+
+	suite.Run(t)
+
+	for _, fn := range []DecodeHookFunc{StringToMapHookFunc("", "="), StringToMapHookFunc(",", "")} {
+		_, err := DecodeHookExec(fn, reflect.ValueOf("a=1"), reflect.ValueOf(map[string]string{}))
+		if err == nil || err.Error() != "empty separator" {
+			t.Fatalf("expected empty separator error, got %v", err)
+		}
+	}
+}
+
+func TestStringToMapHookFunc_Decode(t *testing.T) {
+	type Config struct {
+		Labels  map[string]string
+		Weights map[string]int
+		Limits  map[string]time.Duration
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: ComposeDecodeHookFunc(
+			StringToMapHookFunc(";", ":"),
+			StringToTimeDurationHookFunc(),
+		),
+		WeaklyTypedInput: true,
+		Result:           &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	input := map[string]any{
+		"labels":  "team:core;env:prod",
+		"weights": "a:1;b:2",
+		"limits":  "read:5s;write:1m",
+	}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{
+		Labels:  map[string]string{"team": "core", "env": "prod"},
+		Weights: map[string]int{"a": 1, "b": 2},
+		Limits:  map[string]time.Duration{"read": 5 * time.Second, "write": time.Minute},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+
+	cases := []struct {
+		input map[string]any
+		err   string
+	}{
+		{map[string]any{"labels": "team:core;team:ops"}, "'Labels[team]' duplicate key"},
+		{map[string]any{"weights": "a:1;b:x"}, "'Weights[b]' cannot parse value as 'int'"},
+		{map[string]any{"limits": "read:soon"}, "'Limits[read]' time: invalid duration"},
+	}
+
+	for _, tc := range cases {
+		var result Config
+		decoder, err := NewDecoder(&DecoderConfig{
+			DecodeHook: ComposeDecodeHookFunc(
+				StringToMapHookFunc(";", ":"),
+				StringToTimeDurationHookFunc(),
+			),
+			WeaklyTypedInput: true,
+			Result:           &result,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		err = decoder.Decode(tc.input)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected error %q, got %v", tc.err, err)
+		}
+	}
+}
+
+func TestStringToTypedSliceHookFunc(t *testing.T) {
+	suite := decodeHookTestSuite[string, []string]{
+		fn: StringToTypedSliceHookFunc(","),
+		ok: []decodeHookTestCase[string, []string]{
+			{"", []string{}},
+			{"  ", []string{}},
+			{"a", []string{"a"}},
+			{" a , b ,c", []string{"a", "b", "c"}},
+			{"a,,b,", []string{"a", "", "b", ""}},
+			{`"a,b", " c ",d`, []string{"a,b", " c ", "d"}},
+			{`"say ""hi""",x"y`, []string{`say "hi"`, `x"y`}},
+			{`""`, []string{""}},
+		},
+		fail: []decodeHookFailureTestCase[string, []string]{
+			{`a,"b`},
+			{`"a"b,c`},
+		},
+	}
+
+	suite.Run(t)
+}
+
+func TestStringToTypedSliceHookFunc_Decode(t *testing.T) {
+	type Config struct {
+		Ports    []int
+		Timeouts []time.Duration
+		Flags    [3]bool
+		Names    []*string
+		Key      []byte
+		Single   []string
+	}
+
+	decode := func(input map[string]any) (Config, error) {
+		var result Config
+		decoder, err := NewDecoder(&DecoderConfig{
+			DecodeHook: ComposeDecodeHookFunc(
+				StringToTypedSliceHookFunc(","),
+				StringToTimeDurationHookFunc(),
+				StringToBasicTypeHookFunc(),
+			),
+			Result: &result,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		return result, decoder.Decode(input)
+	}
+
+	result, err := decode(map[string]any{
+		"ports":    "80, 443",
+		"timeouts": "10s,1m",
+		"flags":    "true,false",
+		"names":    `"a, b",c`,
+		"key":      []byte("k,v"),
+		"single":   "x",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	a, c := "a, b", "c"
+	expected := Config{
+		Ports:    []int{80, 443},
+		Timeouts: []time.Duration{10 * time.Second, time.Minute},
+		Flags:    [3]bool{true, false, false},
+		Names:    []*string{&a, &c},
+		Key:      []byte("k,v"),
+		Single:   []string{"x"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+
+	cases := []struct {
+		input map[string]any
+		err   string
+	}{
+		{map[string]any{"ports": "80,http"}, "'Ports[1]' strconv.ParseInt: invalid syntax"},
+		{map[string]any{"timeouts": "10s, soon"}, "'Timeouts[1]' time: invalid duration"},
+		{map[string]any{"ports": `80,"443`}, "'Ports[1]' unterminated quoted string"},
+		{map[string]any{"flags": "true,true,true,true"}, "'Flags' expected source data to have length less or equal to 3, got 4"},
+	}
+
+	for _, tc := range cases {
+		_, err := decode(tc.input)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected error %q, got %v", tc.err, err)
+		}
+	}
+}
+
+func TestHookWhen(t *testing.T) {
+	var paths []string
+	hook := HookWhen(
+		func(path string, from reflect.Value, to reflect.Value) bool {
+			paths = append(paths, path)
+			return from.Kind() == reflect.String && to.Kind() == reflect.String
+		},
+		func(f reflect.Type, t reflect.Type, data any) (any, error) {
+			return strings.ToUpper(data.(string)), nil
+		},
+	)
+
+	var result struct {
+		Name  string
+		Tags  []string
+		Count int
+	}
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook:       hook,
+		WeaklyTypedInput: true,
+		Result:           &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{"name": "a", "tags": []string{"b"}, "count": "1"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Name != "A" || !reflect.DeepEqual(result.Tags, []string{"B"}) || result.Count != 1 {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	sort.Strings(paths)
+	expected := []string{"", "Count", "Name", "Tags", "Tags[0]"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("expected paths %q, got %q", expected, paths)
+	}
+}
+
+func TestHookForType(t *testing.T) {
+	type Level int
+
+	var result struct {
+		Level   Level
+		Retries int
+	}
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: HookForType[Level](func(f reflect.Type, t reflect.Type, data any) (any, error) {
+			if f.Kind() != reflect.String {
+				return data, nil
+			}
+			if data == "high" {
+				return 2, nil
+			}
+
+			return nil, errors.New("unknown level")
+		}),
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(map[string]any{"level": "high", "retries": 3}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if result.Level != 2 || result.Retries != 3 {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	err = decoder.Decode(map[string]any{"level": "low", "retries": "x"})
+	if err == nil ||
+		!strings.Contains(err.Error(), "'Level' unknown level") ||
+		!strings.Contains(err.Error(), "'Retries' expected type 'int', got unconvertible type 'string'") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestHookForPath(t *testing.T) {
+	type TLS struct {
+		Cert string
+		Key  string
+	}
+
+	type Server struct {
+		Name string
+		TLS  TLS `mapstructure:"tls"`
+	}
+
+	type Path string
+
+	type Config struct {
+		Name    string
+		Servers []Server
+		Env     map[string]Path
+	}
+
+	prefix := func(p string) DecodeHookFunc {
+		return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+			if f.Kind() != reflect.String {
+				return data, nil
+			}
+
+			return p + data.(string), nil
+		}
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: ComposeDecodeHookFunc(
+			HookForPath("servers.*.tls.*", prefix("/etc/")),
+			HookForPath("env[home]", HookForType[Path](prefix("~"))),
+			HookForPath("**.name", prefix("n:")),
+		),
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{
+		"name": "root",
+		"servers": []any{
+			map[string]any{"name": "a", "tls": map[string]any{"cert": "a.crt", "key": "a.key"}},
+			map[string]any{"name": "b"},
+		},
+		"env": map[string]any{"home": "/me", "user": "me"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{
+		Name: "n:root",
+		Servers: []Server{
+			{Name: "n:a", TLS: TLS{Cert: "/etc/a.crt", Key: "/etc/a.key"}},
+			{Name: "n:b"},
+		},
+		Env: map[string]Path{"home": "~/me", "user": "me"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"", "", true},
+		{"", "Name", false},
+		{"name", "Name", true},
+		{"servers.*.tls", "Servers[0].TLS", true},
+		{"servers[*].tls", "Servers[12].TLS", true},
+		{"servers.*.tls", "Servers[0].TLS.Cert", false},
+		{"servers.*", "Servers", false},
+		{"servers.?", "Servers[1]", true},
+		{"servers.?", "Servers[10]", false},
+		{"**", "", true},
+		{"**", "A.B[0]", true},
+		{"**.cert", "Servers[0].TLS.Cert", true},
+		{"servers.**.cert", "Servers.Cert", true},
+		{"tls*", "TLSConfig", true},
+		{"env[my.key]", "Env[my.key]", true},
+		{"Servers.B*", "servers.beta", true},
+		{"servers.b?ta", "Servers.Gamma", false},
+		{"SERVERS..TLS", "servers.tls", true},
+	}
+
+	for _, tc := range cases {
+		if match := matchPath(splitPath(tc.pattern), tc.path); match != tc.match {
+			t.Errorf("matchPath(%q, %q) = %t, want %t", tc.pattern, tc.path, match, tc.match)
+		}
+	}
+
+	pattern := splitPath("**.servers.*.tls")
+	if allocs := testing.AllocsPerRun(100, func() {
+		matchPath(pattern, "Config.Servers[0].TLS")
+	}); allocs != 0 {
+		t.Errorf("matchPath allocated %v times", allocs)
+	}
+}
@@ -0,0 +1,284 @@
+package mapstructure
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DurationForms selects the forms of durations accepted by
+// ExtendedDurationHookFunc in addition to the strings accepted by
+// time.ParseDuration.
+type DurationForms uint
+
+const (
+	// DurationDays accepts the units "d" (24h) and "w" (7d), as in "7d"
+	// or "1w2d12h".
+	DurationDays DurationForms = 1 << iota
+
+	// DurationISO8601 accepts ISO 8601 durations such as "PT15M" or
+	// "P1DT12H". Years and months are rejected as they have no fixed
+	// length.
+	DurationISO8601
+
+	// DurationNumbers accepts integers and floats, from numeric types,
+	// json.Number or strings without a unit, as a number of the unit
+	// passed to ExtendedDurationHookFunc.
+	DurationNumbers
+)
+
+// parseExtendedDuration parses s as a duration in any of the given forms.
+func parseExtendedDuration(s string, forms DurationForms, unit time.Duration) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	if forms&DurationNumbers != 0 {
+		if d, ok, err := parseNumericDuration(s, unit); ok {
+			return d, err
+		}
+	}
+
+	neg := false
+	rest := s
+	if strings.HasPrefix(rest, "-") || strings.HasPrefix(rest, "+") {
+		neg = rest[0] == '-'
+		rest = rest[1:]
+	}
+
+	if forms&DurationISO8601 != 0 && strings.HasPrefix(rest, "P") {
+		d, err := parseISO8601Duration(rest[1:])
+		if neg {
+			d = -d
+		}
+
+		return d, err
+	}
+
+	if forms&DurationDays == 0 {
+		d, err := time.ParseDuration(s)
+		return d, wrapTimeParseDurationError(err)
+	}
+
+	if rest == "0" {
+		return 0, nil
+	}
+	if rest == "" {
+		return 0, errors.New("invalid duration")
+	}
+
+	var total time.Duration
+	for rest != "" {
+		end := strings.IndexFunc(rest, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if end <= 0 {
+			return 0, errors.New("invalid duration")
+		}
+
+		number := rest[:end]
+		rest = rest[end:]
+
+		end = strings.IndexFunc(rest, func(r rune) bool {
+			return (r >= '0' && r <= '9') || r == '.'
+		})
+		if end < 0 {
+			end = len(rest)
+		}
+
+		d, err := durationSegment(number, rest[:end])
+		if err != nil {
+			return 0, err
+		}
+		rest = rest[end:]
+
+		if total, err = addDurations(total, d); err != nil {
+			return 0, err
+		}
+	}
+
+	if neg {
+		total = -total
+	}
+
+	return total, nil
+}
+
+// parseISO8601Duration parses the part of an ISO 8601 duration following
+// the "P" designator.
+func parseISO8601Duration(s string) (time.Duration, error) {
+	date, clock, hasTime := strings.Cut(s, "T")
+	if date == "" && clock == "" || hasTime && clock == "" {
+		return 0, errors.New("invalid ISO 8601 duration")
+	}
+
+	var total time.Duration
+	for _, part := range []struct {
+		s     string
+		units map[byte]string
+	}{
+		{date, map[byte]string{'W': "w", 'D': "d"}},
+		{clock, map[byte]string{'H': "h", 'M': "m", 'S': "s"}},
+	} {
+		rest := part.s
+		for rest != "" {
+			end := strings.IndexFunc(rest, func(r rune) bool {
+				return (r < '0' || r > '9') && r != '.' && r != ','
+			})
+			if end <= 0 {
+				return 0, errors.New("invalid ISO 8601 duration")
+			}
+
+			unit, ok := part.units[rest[end]]
+			if !ok {
+				if rest[end] == 'Y' || rest[end] == 'M' {
+					return 0, errors.New("ISO 8601 years and months are not supported")
+				}
+
+				return 0, errors.New("invalid ISO 8601 duration")
+			}
+
+			d, err := durationSegment(strings.Replace(rest[:end], ",", ".", 1), unit)
+			if err != nil {
+				return 0, err
+			}
+			rest = rest[end+1:]
+
+			if total, err = addDurations(total, d); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return total, nil
+}
+
+// durationSegment returns the duration of number in the given unit, one
+// of the units accepted by time.ParseDuration, "d" or "w".
+func durationSegment(number string, unit string) (time.Duration, error) {
+	var mult time.Duration = 1
+	switch unit {
+	case "d":
+		unit, mult = "h", 24
+	case "w":
+		unit, mult = "h", 7*24
+	}
+
+	d, err := time.ParseDuration(number + unit)
+	if err != nil {
+		return 0, wrapTimeParseDurationError(err)
+	}
+
+	if d > math.MaxInt64/mult {
+		return 0, errors.New("time: invalid duration")
+	}
+
+	return d * mult, nil
+}
+
+// addDurations returns a+b for non-negative durations, failing on
+// overflow.
+func addDurations(a, b time.Duration) (time.Duration, error) {
+	if a > math.MaxInt64-b {
+		return 0, errors.New("time: invalid duration")
+	}
+
+	return a + b, nil
+}
+
+// parseNumericDuration parses s as a number of unit. It reports whether s
+// is a number at all.
+func parseNumericDuration(s string, unit time.Duration) (time.Duration, bool, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		d, err := intDuration(i, unit)
+		return d, true, err
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) && numErr.Err == strconv.ErrRange {
+			return 0, true, errors.New("time: invalid duration")
+		}
+
+		return 0, false, nil
+	}
+
+	d, err := floatDuration(f, unit)
+
+	return d, true, err
+}
+
+func intDuration(i int64, unit time.Duration) (time.Duration, error) {
+	d := time.Duration(i) * unit
+	if i != 0 && d/unit != time.Duration(i) {
+		return 0, errors.New("time: invalid duration")
+	}
+
+	return d, nil
+}
+
+func floatDuration(f float64, unit time.Duration) (time.Duration, error) {
+	ns := math.Round(f * float64(unit))
+	if math.IsNaN(ns) || ns >= math.MaxInt64 || ns < math.MinInt64 {
+		return 0, errors.New("time: invalid duration")
+	}
+
+	return time.Duration(ns), nil
+}
+
+// extendedDuration converts data to a time.Duration in the given forms.
+// It reports whether data is of a type that can be converted.
+func extendedDuration(data any, forms DurationForms, unit time.Duration) (time.Duration, bool, error) {
+	v := reflect.ValueOf(data)
+
+	if n, ok := data.(json.Number); ok {
+		if forms&DurationNumbers == 0 {
+			return 0, false, nil
+		}
+
+		d, ok, err := parseNumericDuration(string(n), unit)
+		if !ok {
+			err = errors.New("time: invalid duration")
+		}
+
+		return d, true, err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		d, err := parseExtendedDuration(v.String(), forms, unit)
+		return d, true, err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if forms&DurationNumbers == 0 {
+			return 0, false, nil
+		}
+
+		d, err := intDuration(v.Int(), unit)
+
+		return d, true, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if forms&DurationNumbers == 0 {
+			return 0, false, nil
+		}
+		if v.Uint() > math.MaxInt64 {
+			return 0, true, errors.New("time: invalid duration")
+		}
+
+		d, err := intDuration(int64(v.Uint()), unit)
+
+		return d, true, err
+	case reflect.Float32, reflect.Float64:
+		if forms&DurationNumbers == 0 {
+			return 0, false, nil
+		}
+
+		d, err := floatDuration(v.Float(), unit)
+
+		return d, true, err
+	default:
+		return 0, false, nil
+	}
+}
@@ -0,0 +1,183 @@
+package mapstructure
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Enums is a registry of the names of enum values, keyed by enum type,
+// used to decode enums from their names and encode them back. Names are
+// matched case-insensitively, an exact match taking precedence. See
+// Enums.HookPair.
+//
+// The zero value is an empty registry ready to use. An Enums must not be
+// modified while a Decoder is using it.
+type Enums struct {
+	tables map[reflect.Type]*enumTable
+}
+
+type enumTable struct {
+	values map[string]any
+	names  map[any]string
+	sorted []string
+}
+
+// enumInteger is the set of types usable with RegisterEnumStringer.
+type enumInteger interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// RegisterEnum registers the names of the values of the enum type T in e,
+// replacing any names previously registered for T. If a value has several
+// names, it is encoded with the first of them in lexical order.
+//
+//	var enums Enums
+//	RegisterEnum(&enums, map[string]Level{
+//	    "debug": LevelDebug,
+//	    "info":  LevelInfo,
+//	})
+func RegisterEnum[T comparable](e *Enums, names map[string]T) {
+	var zero T
+	t := reflect.TypeOf(&zero).Elem()
+
+	table := &enumTable{
+		values: make(map[string]any, len(names)),
+		names:  make(map[any]string, len(names)),
+		sorted: make([]string, 0, len(names)),
+	}
+	for name, value := range names {
+		table.values[name] = value
+		table.sorted = append(table.sorted, name)
+	}
+	sort.Strings(table.sorted)
+
+	for _, name := range table.sorted {
+		value := table.values[name]
+		if _, ok := table.names[value]; !ok {
+			table.names[value] = name
+		}
+	}
+
+	if e.tables == nil {
+		e.tables = make(map[reflect.Type]*enumTable)
+	}
+	e.tables[t] = table
+}
+
+// RegisterEnumStringer registers the values of the enum type T from first
+// to last, inclusive, in e, each named by its String method.
+//
+//	var enums Enums
+//	RegisterEnumStringer(&enums, LevelDebug, LevelError)
+func RegisterEnumStringer[T interface {
+	enumInteger
+	fmt.Stringer
+}](e *Enums, first, last T) {
+	names := make(map[string]T)
+	for v := first; v <= last; v++ {
+		names[v.String()] = v
+
+		// Stop before overflowing when last is the largest value of T.
+		if v == last {
+			break
+		}
+	}
+
+	RegisterEnum(e, names)
+}
+
+// lookup returns the value named name in the table.
+func (t *enumTable) lookup(name string) (any, bool) {
+	if v, ok := t.values[name]; ok {
+		return v, true
+	}
+
+	for _, n := range t.sorted {
+		if strings.EqualFold(n, name) {
+			return t.values[n], true
+		}
+	}
+
+	return nil, false
+}
+
+// table returns the table registered for t, if any.
+func (e *Enums) table(t reflect.Type) (*enumTable, bool) {
+	if e == nil || e.tables == nil {
+		return nil, false
+	}
+
+	table, ok := e.tables[t]
+
+	return table, ok
+}
+
+// DecodeHook returns a DecodeHookFunc that converts strings to the
+// registered enum value with that name. Unknown names are reported as a
+// ParseError listing the valid names.
+func (e *Enums) DecodeHook() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if f.Kind() != reflect.String || f == t {
+			return data, nil
+		}
+
+		table, ok := e.table(t)
+		if !ok {
+			return data, nil
+		}
+
+		value, ok := table.lookup(reflect.ValueOf(data).String())
+		if !ok {
+			quoted := make([]string, len(table.sorted))
+			for i, name := range table.sorted {
+				quoted[i] = strconv.Quote(name)
+			}
+
+			return nil, &ParseError{
+				Expected: reflect.New(t).Elem(),
+				Value:    data,
+				Err:      fmt.Errorf("unknown name, valid names are %s", strings.Join(quoted, ", ")),
+			}
+		}
+
+		return value, nil
+	}
+}
+
+// EncodeHook returns an encode hook that converts registered enum values
+// to their name. Values without a name are left unchanged.
+func (e *Enums) EncodeHook() DecodeHookFunc {
+	return func(
+		f reflect.Type,
+		t reflect.Type,
+		data any,
+	) (any, error) {
+		if !acceptsString(t) {
+			return data, nil
+		}
+
+		table, ok := e.table(f)
+		if !ok {
+			return data, nil
+		}
+
+		if name, ok := table.names[data]; ok {
+			return name, nil
+		}
+
+		return data, nil
+	}
+}
+
+// HookPair returns the HookPair of DecodeHook and EncodeHook.
+func (e *Enums) HookPair() HookPair {
+	return HookPair{e.DecodeHook(), e.EncodeHook()}
+}
@@ -0,0 +1,160 @@
+package mapstructure
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testEnumLevel int
+
+const (
+	testLevelDebug testEnumLevel = iota
+	testLevelInfo
+	testLevelWarn
+	testLevelError
+)
+
+func (l testEnumLevel) String() string {
+	switch l {
+	case testLevelDebug:
+		return "debug"
+	case testLevelInfo:
+		return "info"
+	case testLevelWarn:
+		return "warn"
+	case testLevelError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+type testEnumColor uint8
+
+func TestEnums(t *testing.T) {
+	t.Parallel()
+
+	var enums Enums
+	RegisterEnumStringer(&enums, testLevelDebug, testLevelError)
+	RegisterEnum(&enums, map[string]testEnumColor{
+		"red":     1,
+		"green":   2,
+		"Crimson": 1,
+	})
+
+	type Config struct {
+		Level  testEnumLevel
+		Levels []testEnumLevel
+		Color  testEnumColor
+		Accent *testEnumColor
+		Raw    testEnumColor
+	}
+
+	input := map[string]any{
+		"level":  "WARN",
+		"levels": []any{"debug", "Error"},
+		"color":  "crimson",
+		"accent": "green",
+		"raw":    7,
+	}
+
+	var result Config
+	config := &DecoderConfig{Result: &result}
+	config.AddHookPairs(enums.HookPair())
+
+	decoder, err := NewDecoder(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	green := testEnumColor(2)
+	expected := Config{
+		Level:  testLevelWarn,
+		Levels: []testEnumLevel{testLevelDebug, testLevelError},
+		Color:  1,
+		Accent: &green,
+		Raw:    7,
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+
+	var encoded map[string]any
+	config = &DecoderConfig{Result: &encoded, RecursiveEncode: true}
+	config.AddHookPairs(enums.HookPair())
+
+	decoder, err = NewDecoder(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expectedMap := map[string]any{
+		"Level":  "warn",
+		"Levels": []any{"debug", "error"},
+		"Color":  "Crimson",
+		"Accent": "green",
+		"Raw":    testEnumColor(7),
+	}
+	if !reflect.DeepEqual(encoded, expectedMap) {
+		t.Fatalf("expected %#v, got %#v", expectedMap, encoded)
+	}
+}
+
+func TestEnums_Error(t *testing.T) {
+	t.Parallel()
+
+	var enums Enums
+	RegisterEnumStringer(&enums, testLevelDebug, testLevelError)
+
+	var result struct {
+		Level testEnumLevel
+	}
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: enums.DecodeHook(),
+		Result:     &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{"level": "verbose"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	expected := `'Level' cannot parse value as 'mapstructure.testEnumLevel': unknown name, valid names are "debug", "error", "info", "warn"`
+	if !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected error containing %q, got %q", expected, err)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected ParseError, got %T", err)
+	}
+}
+
+func TestRegisterEnumStringer_FullRange(t *testing.T) {
+	t.Parallel()
+
+	var enums Enums
+	RegisterEnumStringer(&enums, testByteEnum(250), testByteEnum(255))
+
+	table, ok := enums.table(reflect.TypeOf(testByteEnum(0)))
+	if !ok || len(table.sorted) != 6 {
+		t.Fatalf("unexpected table: %#v", table)
+	}
+}
+
+type testByteEnum uint8
+
+func (b testByteEnum) String() string {
+	return "b" + string(rune('0'+b-250))
+}
@@ -0,0 +1,89 @@
+package mapstructure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// expandEnv replaces the references to variables in s using lookup:
+//
+//   - ${VAR} is replaced by the value of VAR, or an empty string if unset.
+//   - ${VAR:-default} is replaced by default if VAR is unset or empty.
+//   - ${VAR:?message} fails with message if VAR is unset or empty.
+//   - $${ is replaced by a literal ${.
+//
+// Any other dollar sign is kept as is.
+func expandEnv(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		// An escaped reference is written without its first dollar sign.
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+
+		b.WriteString(s[:i])
+		s = s[i+2:]
+
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", errors.New("unterminated variable reference")
+		}
+
+		value, err := expandEnvReference(s[:end], lookup)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(value)
+		s = s[end+1:]
+	}
+}
+
+// expandEnvReference returns the value of the reference expr, the text
+// between "${" and "}".
+func expandEnvReference(expr string, lookup func(string) (string, bool)) (string, error) {
+	name, op, arg := expr, "", ""
+	if i := strings.Index(expr, ":"); i >= 0 {
+		name, op = expr[:i], expr[i:]
+		if len(op) < 2 || op[1] != '-' && op[1] != '?' {
+			return "", fmt.Errorf("invalid variable reference %q", "${"+expr+"}")
+		}
+		op, arg = op[:2], op[2:]
+	}
+
+	if name == "" {
+		return "", errors.New("empty variable name")
+	}
+
+	value, ok := lookup(name)
+	if ok && value != "" {
+		return value, nil
+	}
+
+	switch op {
+	case ":-":
+		return arg, nil
+	case ":?":
+		if arg == "" {
+			arg = "not set"
+		}
+
+		return "", fmt.Errorf("environment variable %s: %s", name, arg)
+	}
+
+	return value, nil
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/url"
 	"reflect"
+	"regexp/syntax"
 	"strconv"
 	"strings"
 	"time"
@@ -60,6 +61,10 @@ func (e *ParseError) Error() string {
 	return fmt.Sprintf("cannot parse value as '%s': %s", e.Expected.Type(), e.Err)
 }
 
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 func (*ParseError) mapstructure() {}
 
 // UnconvertibleTypeError is an error type that indicates a value could not be
@@ -79,6 +84,123 @@ func (e *UnconvertibleTypeError) Error() string {
 
 func (*UnconvertibleTypeError) mapstructure() {}
 
+// UnusedKeysError is an error type that indicates keys of the input did not
+// match any field of a struct, when DecoderConfig.ErrorUnused is true.
+type UnusedKeysError struct {
+	// Keys are the full paths of the keys, sorted, such as "server.port".
+	Keys []string
+
+	// names are the keys relative to the struct, used in the message.
+	names []string
+}
+
+func (e *UnusedKeysError) Error() string {
+	names := e.names
+	if names == nil {
+		names = e.Keys
+	}
+
+	return fmt.Sprintf("has invalid keys: %s", strings.Join(names, ", "))
+}
+
+func (*UnusedKeysError) mapstructure() {}
+
+// UnsetFieldsError is an error type that indicates fields of a struct were
+// not set from the input, when DecoderConfig.ErrorUnset is true.
+type UnsetFieldsError struct {
+	// Fields are the full paths of the fields, sorted, such as
+	// "server.port".
+	Fields []string
+
+	// names are the fields relative to the struct, used in the message.
+	names []string
+}
+
+func (e *UnsetFieldsError) Error() string {
+	names := e.names
+	if names == nil {
+		names = e.Fields
+	}
+
+	return fmt.Sprintf("has unset fields: %s", strings.Join(names, ", "))
+}
+
+func (*UnsetFieldsError) mapstructure() {}
+
+// HookError is an error type that indicates which decode hook failed, by
+// the name given with NamedDecodeHookFunc or, within
+// OrComposeDecodeHookFunc, by its index.
+type HookError struct {
+	// Name is the name of the hook, if it was named.
+	Name string
+
+	// Index is the index of the hook in OrComposeDecodeHookFunc, or -1.
+	Index int
+
+	Err error
+}
+
+// newHookError wraps err, returned by a hook, in a HookError. An error for
+// a path relative to the decoded value, such as "[key]", stays a
+// DecodeError so that the path is kept.
+func newHookError(name string, index int, err error) error {
+	if derr, ok := err.(*DecodeError); ok && strings.HasPrefix(derr.name, "[") {
+		return newDecodeError(derr.name, newHookError(name, index, derr.err))
+	}
+
+	if herr, ok := err.(*HookError); ok && name == "" {
+		name = herr.Name
+		err = herr.Err
+	}
+
+	return &HookError{Name: name, Index: index, Err: err}
+}
+
+func (e *HookError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("hook %q: %s", e.Name, e.Err)
+	}
+
+	return fmt.Sprintf("hook %d: %s", e.Index, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+func (*HookError) mapstructure() {}
+
+// singleFailure is implemented by the errors which wrap several causes of
+// the failure to decode a single value, such as the error of each layout
+// tried. Unlike the errors joined for several values, they are not
+// reported as multiple errors by Decode.
+type singleFailure interface {
+	error
+	singleFailure()
+}
+
+// hookErrors is returned by OrComposeDecodeHookFunc when all of its hooks
+// fail. It wraps the HookError of each hook, and is reported as a single
+// error for the decoded value.
+type hookErrors struct {
+	errs []error
+}
+
+func (e *hookErrors) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func (e *hookErrors) Unwrap() []error { return e.errs }
+
+func (*hookErrors) mapstructure() {}
+
+func (*hookErrors) singleFailure() {}
+
 func wrapStrconvNumError(err error) error {
 	if err == nil {
 		return nil
@@ -242,3 +364,45 @@ func wrapTimeParseLocationError(err error) error {
 
 	return err
 }
+
+func wrapRegexpSyntaxError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if err, ok := err.(*syntax.Error); ok {
+		return &regexpSyntaxError{Err: err}
+	}
+
+	return err
+}
+
+type regexpSyntaxError struct {
+	Err *syntax.Error
+}
+
+func (e *regexpSyntaxError) Error() string {
+	return "error parsing regexp: " + e.Err.Code.String()
+}
+
+func (e *regexpSyntaxError) Unwrap() error { return e.Err }
+
+func wrapMailParseAddressError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("invalid address: %w", err)
+}
+
+func wrapSlogLevelError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if strings.HasPrefix(err.Error(), "slog: level string") {
+		return errors.New("slog: invalid level")
+	}
+
+	return err
+}
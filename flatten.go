@@ -0,0 +1,171 @@
+package mapstructure
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2/internal/errors"
+)
+
+// Flatten converts a nested map into a flat map whose keys are the paths
+// to the leaf values joined with sep. Slice and array elements are
+// addressed by their index:
+//
+//	map[string]any{
+//	    "servers": []any{
+//	        map[string]any{"host": "localhost"},
+//	    },
+//	}
+//
+// is flattened with "." as separator to:
+//
+//	map[string]any{
+//	    "servers.0.host": "localhost",
+//	}
+//
+// Empty maps and slices are kept as leaf values so that they survive a
+// round-trip through Unflatten. Byte slices are treated as leaf values.
+// An empty sep is an error, since the keys could not be split back.
+func Flatten(m map[string]any, sep string) (map[string]any, error) {
+	if sep == "" {
+		return nil, errors.New("empty separator")
+	}
+
+	out := make(map[string]any, len(m))
+	flatten(out, "", reflect.ValueOf(m), sep)
+
+	return out, nil
+}
+
+func flatten(out map[string]any, prefix string, v reflect.Value, sep string) {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	key := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+
+		return prefix + sep + k
+	}
+
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && v.Len() > 0:
+		iter := v.MapRange()
+		for iter.Next() {
+			flatten(out, key(iter.Key().String()), iter.Value(), sep)
+		}
+
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) &&
+		v.Type().Elem().Kind() != reflect.Uint8 && v.Len() > 0:
+		for i := 0; i < v.Len(); i++ {
+			flatten(out, key(strconv.Itoa(i)), v.Index(i), sep)
+		}
+
+	case v.IsValid():
+		out[prefix] = v.Interface()
+
+	default:
+		out[prefix] = nil
+	}
+}
+
+// Unflatten is the inverse of Flatten: it splits every key of m on sep
+// and builds the corresponding nested maps. Maps whose keys are exactly the
+// indices 0 to n-1 are turned into slices, so "servers.0.host" becomes a
+// slice of maps under the "servers" key. The returned map itself is never
+// turned into a slice.
+//
+// Values of m that are maps themselves are merged with the keys built from
+// the flat paths. An error is returned if a key addresses a path below a
+// value that is not a map, e.g. "a.b" when "a" holds a string, or if sep
+// is empty.
+func Unflatten(m map[string]any, sep string) (map[string]any, error) {
+	// Flatten first so that partially nested input is merged key by key.
+	flat, err := Flatten(m, sep)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]any)
+	for _, k := range keys {
+		if err := unflattenKey(out, k, strings.Split(k, sep), flat[k], sep); err != nil {
+			return nil, err
+		}
+	}
+
+	// The root stays a map, even if its keys are all indices.
+	for k, child := range out {
+		out[k] = unflattenSlices(child)
+	}
+
+	return out, nil
+}
+
+func unflattenKey(out map[string]any, key string, parts []string, value any, sep string) error {
+	cur := out
+	for i, part := range parts[:len(parts)-1] {
+		next, ok := cur[part]
+		if !ok {
+			child := make(map[string]any)
+			cur[part] = child
+			cur = child
+			continue
+		}
+
+		child, ok := next.(map[string]any)
+		if !ok {
+			return newDecodeError(key,
+				fmt.Errorf("conflicts with non-map value at %q", strings.Join(parts[:i+1], sep)))
+		}
+		cur = child
+	}
+
+	last := parts[len(parts)-1]
+	if _, ok := cur[last]; ok {
+		return newDecodeError(key, errors.New("conflicts with existing value"))
+	}
+
+	// Empty maps are kept as leaves by Flatten and may be followed by keys
+	// below them, so never store the caller's map.
+	if vm, ok := value.(map[string]any); ok && len(vm) == 0 {
+		value = make(map[string]any)
+	}
+	cur[last] = value
+
+	return nil
+}
+
+// unflattenSlices recursively converts maps keyed by the indices 0 to n-1
+// into slices.
+func unflattenSlices(v any) any {
+	m, ok := v.(map[string]any)
+	if !ok || len(m) == 0 {
+		return v
+	}
+
+	for k, child := range m {
+		m[k] = unflattenSlices(child)
+	}
+
+	s := make([]any, len(m))
+	for k, child := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != k {
+			return m
+		}
+		s[i] = child
+	}
+
+	return s
+}
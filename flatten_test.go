@@ -0,0 +1,290 @@
+package mapstructure
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFlatten(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		input    map[string]any
+		sep      string
+		expected map[string]any
+	}{
+		{
+			"nested maps",
+			map[string]any{
+				"db": map[string]any{
+					"host": "localhost",
+					"port": 5432,
+				},
+				"debug": true,
+			},
+			".",
+			map[string]any{
+				"db.host": "localhost",
+				"db.port": 5432,
+				"debug":   true,
+			},
+		},
+		{
+			"slices",
+			map[string]any{
+				"servers": []any{
+					map[string]any{"host": "a"},
+					map[string]any{"host": "b"},
+				},
+				"tags": []string{"x", "y"},
+			},
+			".",
+			map[string]any{
+				"servers.0.host": "a",
+				"servers.1.host": "b",
+				"tags.0":         "x",
+				"tags.1":         "y",
+			},
+		},
+		{
+			"typed maps and custom separator",
+			map[string]any{
+				"labels": map[string]string{"team": "core"},
+			},
+			"__",
+			map[string]any{
+				"labels__team": "core",
+			},
+		},
+		{
+			"empty values and bytes are leaves",
+			map[string]any{
+				"empty": map[string]any{},
+				"none":  []any{},
+				"nil":   nil,
+				"bytes": []byte("abc"),
+			},
+			".",
+			map[string]any{
+				"empty": map[string]any{},
+				"none":  []any{},
+				"nil":   nil,
+				"bytes": []byte("abc"),
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := Flatten(tc.input, tc.sep)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("expected:\n%#v\ngot:\n%#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		input    map[string]any
+		expected map[string]any
+	}{
+		{
+			"nested maps",
+			map[string]any{
+				"db.host": "localhost",
+				"db.port": 5432,
+				"debug":   true,
+			},
+			map[string]any{
+				"db": map[string]any{
+					"host": "localhost",
+					"port": 5432,
+				},
+				"debug": true,
+			},
+		},
+		{
+			"slice indices",
+			map[string]any{
+				"servers.0.host": "a",
+				"servers.1.host": "b",
+				"tags.0":         "x",
+			},
+			map[string]any{
+				"servers": []any{
+					map[string]any{"host": "a"},
+					map[string]any{"host": "b"},
+				},
+				"tags": []any{"x"},
+			},
+		},
+		{
+			"sparse indices stay maps",
+			map[string]any{
+				"ids.0": "a",
+				"ids.2": "c",
+			},
+			map[string]any{
+				"ids": map[string]any{"0": "a", "2": "c"},
+			},
+		},
+		{
+			"index-only top-level keys",
+			map[string]any{
+				"0":   "a",
+				"1":   "b",
+				"2.0": "c",
+			},
+			map[string]any{
+				"0": "a",
+				"1": "b",
+				"2": []any{"c"},
+			},
+		},
+		{
+			"partially nested input",
+			map[string]any{
+				"db.host": "localhost",
+				"db": map[string]any{
+					"port": 5432,
+				},
+			},
+			map[string]any{
+				"db": map[string]any{
+					"host": "localhost",
+					"port": 5432,
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := Unflatten(tc.input, ".")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("expected:\n%#v\ngot:\n%#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUnflatten_conflict(t *testing.T) {
+	t.Parallel()
+
+	_, err := Unflatten(map[string]any{
+		"db":      "localhost",
+		"db.port": 5432,
+	}, ".")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var derr *DecodeError
+	if !errors.As(err, &derr) || derr.Name() != "db.port" {
+		t.Fatalf("expected DecodeError for 'db.port', got %#v", err)
+	}
+}
+
+func TestFlatten_emptySeparator(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Flatten(map[string]any{"a": map[string]any{"b": 1}}, ""); err == nil {
+		t.Fatal("expected error from Flatten")
+	}
+	if _, err := Unflatten(map[string]any{"ab": 1}, ""); err == nil {
+		t.Fatal("expected error from Unflatten")
+	}
+}
+
+func TestFlatten_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"servers": []any{
+			map[string]any{"host": "a", "ports": []any{1, 2}},
+		},
+		"empty": map[string]any{},
+	}
+
+	flat, err := Flatten(input, ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	actual, err := Unflatten(flat, ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(actual, input) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", input, actual)
+	}
+}
+
+func TestDecoder_FlattenSeparator(t *testing.T) {
+	t.Parallel()
+
+	type Server struct {
+		Host string
+		Port int
+	}
+
+	type Config struct {
+		Name    string
+		Servers []Server
+		Primary *Server
+	}
+
+	input := Config{
+		Name:    "app",
+		Servers: []Server{{Host: "a", Port: 1}},
+		Primary: &Server{Host: "b", Port: 2},
+	}
+
+	result := map[string]any{"existing": true}
+	decoder, err := NewDecoder(&DecoderConfig{
+		FlattenSeparator: ".",
+		Result:           &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]any{
+		"existing":       true,
+		"Name":           "app",
+		"Servers.0.Host": "a",
+		"Servers.0.Port": 1,
+		"Primary.Host":   "b",
+		"Primary.Port":   2,
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", expected, result)
+	}
+}
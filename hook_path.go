@@ -0,0 +1,95 @@
+package mapstructure
+
+import (
+	"path"
+	"strings"
+)
+
+// splitPath splits a decode path, such as "Servers[0].TLS", or a pattern
+// for it into its segments, here "Servers", "0" and "TLS". Struct fields
+// are separated by dots, and slice indexes and map keys are enclosed in
+// brackets.
+func splitPath(p string) []string {
+	var segments []string
+	for {
+		segment, rest, ok := nextPathSegment(p)
+		if !ok {
+			return segments
+		}
+		segments = append(segments, segment)
+		p = rest
+	}
+}
+
+// nextPathSegment returns the first segment of the decode path p and the
+// rest of p, or false if p has no segments left.
+func nextPathSegment(p string) (segment, rest string, ok bool) {
+	p = strings.TrimLeft(p, ".")
+	if p == "" {
+		return "", "", false
+	}
+
+	if p[0] == '[' {
+		end := strings.IndexByte(p, ']')
+		if end < 0 {
+			return p[1:], "", true
+		}
+
+		return p[1:end], p[end+1:], true
+	}
+
+	end := strings.IndexAny(p, ".[")
+	if end < 0 {
+		end = len(p)
+	}
+
+	return p[:end], p[end:], true
+}
+
+// matchPath reports whether the decode path p matches the segments of a
+// pattern, split with splitPath. A "*" segment, or any path.Match
+// pattern, matches a single segment, and a "**" segment matches any
+// number of segments. Segments are compared case-insensitively, like
+// field names. The path is matched as it is scanned, so that matching a
+// hook against every decoded value does not allocate.
+func matchPath(pattern []string, p string) bool {
+	if len(pattern) == 0 {
+		_, _, ok := nextPathSegment(p)
+		return !ok
+	}
+
+	if pattern[0] == "**" {
+		for {
+			if matchPath(pattern[1:], p) {
+				return true
+			}
+
+			_, rest, ok := nextPathSegment(p)
+			if !ok {
+				return false
+			}
+			p = rest
+		}
+	}
+
+	segment, rest, ok := nextPathSegment(p)
+	if !ok || !matchPathSegment(pattern[0], segment) {
+		return false
+	}
+
+	return matchPath(pattern[1:], rest)
+}
+
+// matchPathSegment reports whether a segment of a decode path matches a
+// segment of a pattern.
+func matchPathSegment(pattern, segment string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		return strings.EqualFold(pattern, segment)
+	}
+
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(segment))
+	return err == nil && ok
+}
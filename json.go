@@ -0,0 +1,108 @@
+package mapstructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+var jsonRawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// parseJSON parses the single JSON value in b, decoding numbers as
+// json.Number so that they are converted exactly by the Decoder.
+func parseJSON(b []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+	if rest := bytes.TrimLeft(b[dec.InputOffset():], " \t\r\n"); len(rest) > 0 {
+		return nil, fmt.Errorf("invalid character '%c' after top-level value", rest[0])
+	}
+
+	return v, nil
+}
+
+// isJSONContainer reports whether t is a struct, map or slice type that an
+// embedded JSON string may be decoded into. Byte slices are excluded, as
+// strings are decoded into them as is.
+func isJSONContainer(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+
+	return false
+}
+
+// indirectType returns the type t points to, through any number of
+// pointers.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// isJSONText reports whether s looks like a JSON object or array.
+func isJSONText(s string) bool {
+	s = strings.TrimSpace(s)
+
+	return strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")
+}
+
+// decodeJSON converts data, a string or a json.RawMessage holding JSON,
+// into the parsed value to decode into a value of t. If t is
+// json.RawMessage, data is instead converted to raw JSON: strings are kept
+// as is if they hold valid JSON, and other values are marshaled.
+func decodeJSON(data any, t reflect.Type) (any, error) {
+	v := reflect.ValueOf(data)
+
+	var err error
+	if t == jsonRawMessageType {
+		switch {
+		case v.Type() == jsonRawMessageType:
+			return data, nil
+		case v.Kind() == reflect.String && json.Valid([]byte(v.String())):
+			return json.RawMessage(v.String()), nil
+		}
+
+		var b []byte
+		if b, err = json.Marshal(data); err == nil {
+			return json.RawMessage(b), nil
+		}
+	} else {
+		var b []byte
+		switch {
+		case v.Type() == jsonRawMessageType:
+			b = v.Bytes()
+		case v.Kind() == reflect.String:
+			b = []byte(v.String())
+		default:
+			return data, nil
+		}
+
+		var parsed any
+		if parsed, err = parseJSON(b); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return nil, &ParseError{
+		Expected: reflect.New(t).Elem(),
+		Value:    data,
+		Err:      err,
+	}
+}
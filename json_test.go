@@ -0,0 +1,240 @@
+package mapstructure
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONStringHookFunc(t *testing.T) {
+	t.Parallel()
+
+	type TLS struct {
+		Enabled bool
+		Timeout time.Duration
+	}
+
+	type Server struct {
+		Name     string
+		Port     uint16
+		TLS      *TLS
+		Metadata map[string]any
+		Tags     []string
+		Raw      json.RawMessage
+		Payload  json.RawMessage
+	}
+
+	type Config struct {
+		Servers []Server
+	}
+
+	decode := func(input map[string]any) (Config, error) {
+		var result Config
+		decoder, err := NewDecoder(&DecoderConfig{
+			DecodeHook: ComposeDecodeHookFunc(
+				JSONStringHookFunc(),
+				StringToTimeDurationHookFunc(),
+			),
+			Result: &result,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		return result, decoder.Decode(input)
+	}
+
+	result, err := decode(map[string]any{
+		"servers": `[{"name": "a", "port": 8080, "tls": "{\"enabled\": true, \"timeout\": \"5s\"}"}]`,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []Server{{Name: "a", Port: 8080, TLS: &TLS{Enabled: true, Timeout: 5 * time.Second}}}
+	if !reflect.DeepEqual(result.Servers, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result.Servers)
+	}
+
+	result, err = decode(map[string]any{
+		"servers": []any{
+			map[string]any{
+				"name":     "b",
+				"metadata": `{"id": 12345678901234567890, "ratio": 0.5}`,
+				"tags":     json.RawMessage(`["x", "y"]`),
+				"raw":      `{"kept": true}`,
+				"payload":  map[string]any{"n": 1},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected = []Server{
+		{
+			Name:     "b",
+			Metadata: map[string]any{"id": json.Number("12345678901234567890"), "ratio": json.Number("0.5")},
+			Tags:     []string{"x", "y"},
+			Raw:      json.RawMessage(`{"kept": true}`),
+			Payload:  json.RawMessage(`{"n":1}`),
+		},
+	}
+	if !reflect.DeepEqual(result.Servers, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result.Servers)
+	}
+
+	cases := []struct {
+		input map[string]any
+		err   string
+	}{
+		{
+			map[string]any{"servers": `[{"name": "a", "tls": "{\"enabled\": tru}"}]`},
+			"'Servers[0].TLS' cannot parse value as 'mapstructure.TLS': invalid character",
+		},
+		{
+			map[string]any{"servers": `[{"name": "a", "port": true}]`},
+			"'Servers[0].Port' expected type 'uint16'",
+		},
+		{
+			map[string]any{"servers": `[{"name": "a"}] x`},
+			"'Servers' cannot parse value as '[]mapstructure.Server': invalid character 'x' after top-level value",
+		},
+		{
+			map[string]any{"servers": `[{"name": "a"}`},
+			"'Servers' cannot parse value as '[]mapstructure.Server': unexpected EOF",
+		},
+	}
+
+	for _, tc := range cases {
+		var result Config
+		decoder, err := NewDecoder(&DecoderConfig{
+			DecodeHook: JSONStringHookFunc(),
+			Result:     &result,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		err = decoder.Decode(tc.input)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected error %q, got %v", tc.err, err)
+		}
+	}
+}
+
+func TestJSONStringHookFunc_NotJSON(t *testing.T) {
+	t.Parallel()
+
+	var result struct {
+		Tags  []string
+		Bytes []byte
+		Name  string
+	}
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: ComposeDecodeHookFunc(
+			JSONStringHookFunc(),
+			StringToSliceHookFunc(","),
+		),
+		WeaklyTypedInput: true,
+		Result:           &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	input := map[string]any{"tags": "a,b", "bytes": "[1]", "name": `{"a":1}`}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(result.Tags, []string{"a", "b"}) || string(result.Bytes) != "[1]" || result.Name != `{"a":1}` {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+}
+
+func TestDecode_JSONTag(t *testing.T) {
+	t.Parallel()
+
+	type Limits struct {
+		Max int
+	}
+
+	type Event struct {
+		Metadata map[string]any   `mapstructure:"metadata,json"`
+		Limits   *Limits          `mapstructure:"limits,json"`
+		Count    int              `mapstructure:"count,json"`
+		Raw      json.RawMessage  `mapstructure:"raw,json"`
+		Other    *json.RawMessage `mapstructure:"other,json"`
+		Plain    map[string]any   `mapstructure:"plain,json"`
+	}
+
+	input := map[string]any{
+		"metadata": `{"a": 1}`,
+		"limits":   ` {"max": 3}`,
+		"count":    "42",
+		"raw":      []any{1, "x"},
+		"other":    `"text"`,
+		"plain":    map[string]any{"b": 2},
+	}
+
+	var result Event
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	other := json.RawMessage(`"text"`)
+	expected := Event{
+		Metadata: map[string]any{"a": json.Number("1")},
+		Limits:   &Limits{Max: 3},
+		Count:    42,
+		Raw:      json.RawMessage(`[1,"x"]`),
+		Other:    &other,
+		Plain:    map[string]any{"b": 2},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+
+	err := Decode(map[string]any{"count": "4x", "metadata": "a=1"}, &result)
+	if err == nil ||
+		!strings.Contains(err.Error(), "'count' cannot parse value as 'int': invalid character 'x' after top-level value") ||
+		!strings.Contains(err.Error(), "'metadata' cannot parse value as 'map[string]interface {}': invalid character 'a'") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected ParseError, got %T", err)
+	}
+}
+
+func TestDecode_JSONTagAfterHook(t *testing.T) {
+	t.Parallel()
+
+	type Event struct {
+		Metadata map[string]any `mapstructure:"metadata,json"`
+	}
+
+	var result Event
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook: ExpandEnvHookFunc(func(name string) (string, bool) {
+			return `{"a": 1}`, name == "METADATA"
+		}),
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(map[string]any{"metadata": "${METADATA}"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]any{"a": json.Number("1")}
+	if !reflect.DeepEqual(result.Metadata, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result.Metadata)
+	}
+}
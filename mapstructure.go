@@ -147,6 +147,31 @@
 //	    URLs []string `mapstructure:",omitzero"`
 //	}
 //
+// # Encoded Bytes
+//
+// A []byte or [N]byte field may use the ",encoding=base64",
+// ",encoding=base64url" or ",encoding=hex" suffix on its tag. Strings are
+// then decoded into the field with that encoding, padding being optional
+// for base64, and the field is encoded back to such a string when decoding
+// from the struct to a map. A [N]byte field requires exactly N bytes.
+//
+//	type Keys struct {
+//	    Secret []byte   `mapstructure:"secret,encoding=base64"`
+//	    Hash   [32]byte `mapstructure:"hash,encoding=hex"`
+//	}
+//
+// # Embedded JSON
+//
+// A field may use the ",json" suffix on its tag to decode strings holding
+// JSON, such as "{\"a\":1}", as the value they encode, which is then decoded
+// into the field. A json.RawMessage field instead keeps strings holding valid
+// JSON as is and receives other values marshaled to JSON. See also
+// JSONStringHookFunc.
+//
+//	type Event struct {
+//	    Metadata map[string]any `mapstructure:"metadata,json"`
+//	}
+//
 // # Unexported fields
 //
 // Since unexported (private) struct fields cannot be set outside the package
@@ -180,12 +205,14 @@
 package mapstructure
 
 import (
+	"encoding"
 	"encoding/json"
 	"fmt"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/go-viper/mapstructure/v2/internal/errors"
 )
@@ -194,8 +221,8 @@ import (
 // data transformations. See "DecodeHook" in the DecoderConfig
 // struct.
 //
-// The type must be one of DecodeHookFuncType, DecodeHookFuncKind, or
-// DecodeHookFuncValue.
+// The type must be one of DecodeHookFuncType, DecodeHookFuncKind,
+// DecodeHookFuncValue, or DecodeHookFuncPath.
 // Values are a superset of Types (Values can return types), and Types are a
 // superset of Kinds (Types can return Kinds) and are generally a richer thing
 // to use, but Kinds are simpler if you only need those.
@@ -218,6 +245,11 @@ type DecodeHookFuncKind func(reflect.Kind, reflect.Kind, any) (any, error)
 // values.
 type DecodeHookFuncValue func(from reflect.Value, to reflect.Value) (any, error)
 
+// DecodeHookFuncPath is a DecodeHookFunc which additionally knows the path
+// of the value being decoded, as used in errors and metadata, such as
+// "Servers[0].TLS". The path is empty for the root value.
+type DecodeHookFuncPath func(path string, from reflect.Value, to reflect.Value) (any, error)
+
 // DecoderConfig is the configuration that is used to create a new decoder
 // and allows customization of various aspects of decoding.
 type DecoderConfig struct {
@@ -234,13 +266,14 @@ type DecoderConfig struct {
 
 	// If ErrorUnused is true, then it is an error for there to exist
 	// keys in the original map that were unused in the decoding process
-	// (extra keys).
+	// (extra keys). The keys are reported as an UnusedKeysError.
 	ErrorUnused bool
 
 	// If ErrorUnset is true, then it is an error for there to exist
 	// fields in the result that were not set in the decoding process
 	// (extra fields). This only applies to decoding to a struct. This
-	// will affect all nested structs as well.
+	// will affect all nested structs as well. The fields are reported as
+	// an UnsetFieldsError.
 	ErrorUnset bool
 
 	// AllowUnsetPointer, if set to true, will prevent fields with pointer types
@@ -313,6 +346,133 @@ type DecoderConfig struct {
 	// MapFieldName is the function used to convert the struct field name to the map's key name.
 	// This can be used to support snake casing, etc.
 	MapFieldName func(string) string
+
+	// RecursiveEncode, if set to true, will convert structs to maps wherever
+	// they appear when decoding from a struct to a map with interface values:
+	// inside slices, arrays, maps and behind pointers. The result is a tree of
+	// maps, slices and scalars that is suitable for serialization and diffing.
+	//
+	// By default, only directly nested struct fields are converted and any
+	// other value is copied as is.
+	RecursiveEncode bool
+
+	// EncodeTextMarshaler, if set to true, will convert values implementing
+	// encoding.TextMarshaler to strings when decoding from a struct to a map
+	// with interface values. This keeps types such as time.Time, net.IP or
+	// netip.Addr in a form the string based decode hooks understand.
+	EncodeTextMarshaler bool
+
+	// EncodeStringer, if set to true, will convert values implementing
+	// fmt.Stringer to strings when decoding from a struct to a map with
+	// interface values. encoding.TextMarshaler takes precedence if both
+	// options are enabled.
+	EncodeStringer bool
+
+	// FlattenSeparator, if set, will flatten the result when decoding into
+	// a map[string]any: nested maps and slices are replaced by keys joined
+	// with this separator, as returned by Flatten. Nested structs are
+	// converted as if RecursiveEncode was set.
+	FlattenSeparator string
+
+	// EncodeHook, if set, will be called for every value that is put into
+	// a map when decoding from a struct, before any of the encoding options
+	// above is applied. It is the counterpart of DecodeHook and receives the
+	// struct field value together with the map value it is stored into.
+	// Nested values are passed to the hook only if RecursiveEncode is set,
+	// in which case all nested slices and maps are rebuilt with interface
+	// elements.
+	//
+	// If the hook returns a value of a different type, that value is stored
+	// into the map as is. If an error is returned, the entire decode will
+	// fail with that error.
+	EncodeHook DecodeHookFunc
+
+	// Converters, if set, holds conversion functions keyed by source and
+	// target type. After the DecodeHook ran, the conversion registered for
+	// the type of the input and the type of the target, if any, is used
+	// instead of the default decoding. See Register.
+	Converters *Converters
+
+	// TypeDecoders, if set, replaces the default decoding for the target
+	// types used as keys. A key may also be an interface type, in which
+	// case its TypeDecoderFunc is used for all target types T where T or *T
+	// implements the interface. If several interfaces match, the one with
+	// the most methods is used, or else the first one by name. The decode
+	// hook runs before a TypeDecoderFunc.
+	//
+	// Errors returned by a TypeDecoderFunc are wrapped in a DecodeError
+	// holding the path of the value, unless they already implement Error.
+	TypeDecoders map[reflect.Type]TypeDecoderFunc
+
+	// AfterDecode hooks are called in order after all the fields of a
+	// struct have been decoded from a map without error, and before its
+	// Validate method if Validate is set. Nested structs are processed before the struct
+	// holding them. This lets you normalize or cross-check fields once
+	// they are all set.
+	//
+	// If an error is returned, the entire decode will fail with that error,
+	// wrapped in a DecodeError holding the path of the struct.
+	AfterDecode []AfterDecodeHookFunc
+
+	// Validate, if set to true, will call the Validate method of every
+	// struct implementing Validator once its fields have been decoded from
+	// a map, including nested structs. Leave it unset if structs are only
+	// valid once decoding is complete, e.g. after merging defaults.
+	Validate bool
+}
+
+// AfterDecodeHookFunc is called with a struct value after all its fields
+// have been decoded. The value is addressable unless the struct itself is
+// not. See "AfterDecode" in the DecoderConfig struct.
+type AfterDecodeHookFunc func(val reflect.Value) error
+
+// Validator is the interface implemented by types that check their own
+// consistency. If DecoderConfig.Validate is set, after all the fields of a
+// struct implementing Validator have been decoded from a map, its Validate
+// method is called and any error is returned wrapped in a DecodeError
+// holding the path of the struct.
+type Validator interface {
+	Validate() error
+}
+
+// Marshaler is the interface implemented by types that can convert
+// themselves into a value suitable for a map, such as a string or a
+// map[string]any. It is honored when decoding from a struct to a map.
+type Marshaler interface {
+	MarshalMapstructure() (any, error)
+}
+
+// Unmarshaler is the interface implemented by types that can decode
+// themselves from an arbitrary input value. It is typically used together
+// with Marshaler to control both directions of the conversion.
+//
+// UnmarshalMapstructure is called with the input after the decode hook ran,
+// unless the input already has the type of the target, in which case it is
+// copied. Errors are wrapped in a DecodeError holding the path of the
+// value, unless they already implement Error.
+type Unmarshaler interface {
+	UnmarshalMapstructure(any) error
+}
+
+// ContextUnmarshaler is like Unmarshaler, but the method also receives a
+// DecodeContext to decode sub-values through the Decoder, so that decode
+// hooks, error paths and metadata behave as for any other value. It takes
+// precedence over Unmarshaler.
+//
+// To reuse the default decoding of the type itself, decode into a value of
+// a type defined with the same underlying type, which does not implement
+// the interface:
+//
+//	func (e *Endpoint) UnmarshalMapstructureContext(ctx *DecodeContext, input any) error {
+//	    if s, ok := input.(string); ok {
+//	        return e.parse(s)
+//	    }
+//
+//	    type plain Endpoint
+//	    return ctx.Decode(input, reflect.ValueOf((*plain)(e)).Elem())
+//	}
+type ContextUnmarshaler interface {
+	UnmarshalMapstructureContext(ctx *DecodeContext, input any) error
 }
 
 // A Decoder takes a raw interface value and turns it into structured
@@ -323,7 +483,24 @@ type DecoderConfig struct {
 // up the most basic Decoder.
 type Decoder struct {
 	config           *DecoderConfig
-	cachedDecodeHook func(from reflect.Value, to reflect.Value) (any, error)
+	cachedDecodeHook func(path string, from reflect.Value, to reflect.Value) (any, error)
+	cachedEncodeHook func(path string, from reflect.Value, to reflect.Value) (any, error)
+	typeDecoderCache map[reflect.Type]TypeDecoderFunc
+
+	// typeDecoderInterfaces are the interface types of TypeDecoders, in
+	// the order they are matched.
+	typeDecoderInterfaces []reflect.Type
+
+	// intermediate is set while a struct is converted into the map used to
+	// decode it into another struct. The encode options and the EncodeHook
+	// only apply to maps the caller asked for, so they are skipped then.
+	intermediate bool
+
+	// encodeDepth is the number of pointers, maps and slices followed by
+	// encodeContents. Past encodeCycleDepth, the ones being followed are
+	// kept in encodeVisits to detect cycles.
+	encodeDepth  int
+	encodeVisits map[encodeVisit]struct{}
 }
 
 // Metadata contains information about decoding a structure that
@@ -461,6 +638,10 @@ func NewDecoder(config *DecoderConfig) (*Decoder, error) {
 	if config.DecodeHook != nil {
 		result.cachedDecodeHook = cachedDecodeHook(config.DecodeHook)
 	}
+	if config.EncodeHook != nil {
+		result.cachedEncodeHook = cachedDecodeHook(config.EncodeHook)
+	}
+	result.typeDecoderInterfaces = typeDecoderInterfaces(config.TypeDecoders)
 
 	return result, nil
 }
@@ -468,17 +649,46 @@ func NewDecoder(config *DecoderConfig) (*Decoder, error) {
 // Decode decodes the given raw interface to the target pointer specified
 // by the configuration.
 func (d *Decoder) Decode(input any) error {
-	err := d.decode("", input, reflect.ValueOf(d.config.Result).Elem())
+	var err error
+	outVal := reflect.ValueOf(d.config.Result).Elem()
+	if d.config.FlattenSeparator != "" && outVal.Type() == mapStringAnyType {
+		err = d.decodeFlatten(input, outVal)
+	} else {
+		err = d.decode("", input, outVal)
+	}
 
 	// Retain some of the original behavior when multiple errors ocurr
-	var joinedErr interface{ Unwrap() []error }
-	if errors.As(err, &joinedErr) {
+	if isJoinedError(err) {
 		return fmt.Errorf("decoding failed due to the following error(s):\n\n%w", err)
 	}
 
 	return err
 }
 
+// decodeFlatten decodes input into a nested map and stores its flattened
+// form into val.
+func (d *Decoder) decodeFlatten(input any, val reflect.Value) error {
+	nested := reflect.New(mapStringAnyType).Elem()
+	if err := d.decode("", input, nested); err != nil {
+		return err
+	}
+
+	flat, err := Flatten(nested.Interface().(map[string]any), d.config.FlattenSeparator)
+	if err != nil {
+		return err
+	}
+	if val.IsNil() || d.config.ZeroFields {
+		val.Set(reflect.ValueOf(flat))
+		return nil
+	}
+
+	for k, v := range flat {
+		val.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(&v).Elem())
+	}
+
+	return nil
+}
+
 // isNil returns true if the input is nil or a typed nil pointer.
 func isNil(input any) bool {
 	if input == nil {
@@ -490,6 +700,23 @@ func isNil(input any) bool {
 
 // Decodes an unknown data type into a specific reflection value.
 func (d *Decoder) decode(name string, input any, outVal reflect.Value) error {
+	return d.decodeField(name, input, outVal, fieldOptions{})
+}
+
+// fieldOptions are the options of the tag of a struct field that change
+// how the field is decoded. They apply to the input once the DecodeHook
+// has run.
+type fieldOptions struct {
+	// encoding is the value of the ",encoding=" option.
+	encoding string
+
+	// json is set by the ",json" option.
+	json bool
+}
+
+// decodeField decodes input into outVal like decode, applying the options
+// of the struct field being decoded.
+func (d *Decoder) decodeField(name string, input any, outVal reflect.Value, opts fieldOptions) error {
 	var (
 		inputVal   = reflect.ValueOf(input)
 		outputKind = getKind(outVal)
@@ -539,17 +766,117 @@ func (d *Decoder) decode(name string, input any, outVal reflect.Value) error {
 	if d.cachedDecodeHook != nil {
 		// We have a DecodeHook, so let's pre-process the input.
 		var err error
-		input, err = d.cachedDecodeHook(inputVal, outVal)
+		input, err = d.cachedDecodeHook(name, inputVal, outVal)
 		if err != nil {
-			return newDecodeError(name, err)
+			return hookError(name, err)
 		}
 	}
 	if isNil(input) {
 		return nil
 	}
 
+	if opts.encoding != "" {
+		var err error
+		if input, err = decodeTaggedBytes(opts.encoding, input, outVal.Type()); err != nil {
+			return newDecodeError(name, err)
+		}
+	}
+	if opts.json {
+		var err error
+		if input, err = decodeJSON(input, indirectType(outVal.Type())); err != nil {
+			return newDecodeError(name, err)
+		}
+	}
+
+	if ok, err := d.decodeConverter(name, input, outVal); ok {
+		if err == nil && d.config.Metadata != nil && name != "" {
+			d.config.Metadata.Keys = append(d.config.Metadata.Keys, name)
+		}
+
+		return err
+	}
+
+	if fn, ok := d.typeDecoder(outVal.Type()); ok {
+		err := fn(&DecodeContext{d: d, name: name}, input, outVal)
+		if err != nil {
+			var merr Error
+			if !errors.As(err, &merr) {
+				err = newDecodeError(name, err)
+			}
+
+			return err
+		}
+
+		d.addSelfDecodedMetaKey(name)
+
+		return nil
+	}
+
+	return d.decodeDefault(name, input, outVal)
+}
+
+// addSelfDecodedMetaKey marks name as used after a TypeDecoderFunc or an
+// unmarshaler decoded it. These may have recursed into the default
+// decoding at the same path, which already marked it.
+func (d *Decoder) addSelfDecodedMetaKey(name string) {
+	if d.config.Metadata == nil || name == "" {
+		return
+	}
+
+	keys := d.config.Metadata.Keys
+	if len(keys) > 0 && keys[len(keys)-1] == name {
+		return
+	}
+
+	d.config.Metadata.Keys = append(keys, name)
+}
+
+// isJoinedError reports whether err joins the errors of several values.
+// The errors wrapping the causes of a single failure, which implement
+// singleFailure, do not.
+func isJoinedError(err error) bool {
+	for err != nil {
+		switch e := err.(type) {
+		case singleFailure:
+			return false
+		case interface{ Unwrap() []error }:
+			return true
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// hookError wraps err, returned by a decode hook for the value at name,
+// with name. A hook reports an error about an element of the value, such
+// as a map entry, with a DecodeError named by the path of the element
+// relative to the value, such as "[key]".
+func hookError(name string, err error) error {
+	if derr, ok := err.(*DecodeError); ok && strings.HasPrefix(derr.name, "[") {
+		return newDecodeError(name+derr.name, derr.err)
+	}
+
+	return newDecodeError(name, err)
+}
+
+// decodeDefault decodes input into outVal once the decode hook, the
+// converters and the type decoders have been applied.
+func (d *Decoder) decodeDefault(name string, input any, outVal reflect.Value) error {
+	if ok, err := d.decodeUnmarshaler(name, input, outVal); ok {
+		if err == nil {
+			d.addSelfDecodedMetaKey(name)
+		}
+
+		return err
+	}
+
 	var err error
 	addMetaKey := true
+	outputKind := getKind(outVal)
 	switch outputKind {
 	case reflect.Bool:
 		err = d.decodeBool(name, input, outVal)
@@ -566,7 +893,14 @@ func (d *Decoder) decode(name string, input any, outVal reflect.Value) error {
 	case reflect.Complex64:
 		err = d.decodeComplex(name, input, outVal)
 	case reflect.Struct:
-		err = d.decodeStruct(name, input, outVal)
+		switch outVal.Type() {
+		case orderedMapType:
+			err = d.decodeOrderedMap(name, input, outVal)
+		case bigIntType, bigFloatType, bigRatType:
+			err = d.decodeBig(name, input, outVal)
+		default:
+			err = d.decodeStruct(name, input, outVal)
+		}
 	case reflect.Map:
 		err = d.decodeMap(name, input, outVal)
 	case reflect.Ptr:
@@ -591,6 +925,63 @@ func (d *Decoder) decode(name string, input any, outVal reflect.Value) error {
 	return err
 }
 
+// decodeConverter decodes data using the conversion registered in the
+// Converters of the config for the type of data and the type of val. It
+// reports whether a conversion was found.
+func (d *Decoder) decodeConverter(name string, data any, val reflect.Value) (bool, error) {
+	fn, ok := d.config.Converters.lookup(reflect.TypeOf(data), val.Type())
+	if !ok {
+		return false, nil
+	}
+
+	out, err := fn(reflect.ValueOf(data))
+	if err != nil {
+		return true, newDecodeError(name, err)
+	}
+
+	val.Set(out)
+
+	return true, nil
+}
+
+// decodeUnmarshaler decodes data using the UnmarshalMapstructureContext or
+// UnmarshalMapstructure method of val if it implements ContextUnmarshaler
+// or Unmarshaler. It reports whether one of the methods was used.
+func (d *Decoder) decodeUnmarshaler(name string, data any, val reflect.Value) (bool, error) {
+	if !val.CanAddr() {
+		return false, nil
+	}
+
+	ptrType := reflect.PtrTo(val.Type())
+	if !ptrType.Implements(contextUnmarshalerType) && !ptrType.Implements(unmarshalerType) {
+		return false, nil
+	}
+
+	// Values of the exact target type are copied by the regular code path.
+	if reflect.TypeOf(data) == val.Type() {
+		return false, nil
+	}
+
+	var err error
+	switch u := val.Addr().Interface().(type) {
+	case ContextUnmarshaler:
+		err = u.UnmarshalMapstructureContext(&DecodeContext{d: d, name: name}, data)
+	case Unmarshaler:
+		err = u.UnmarshalMapstructure(data)
+	}
+
+	if err != nil {
+		var merr Error
+		if !errors.As(err, &merr) {
+			err = newDecodeError(name, err)
+		}
+
+		return true, err
+	}
+
+	return true, nil
+}
+
 // This decodes a basic type (bool, int, string, etc.) and sets the
 // value to "data" of that type.
 func (d *Decoder) decodeBasic(name string, data any, val reflect.Value) error {
@@ -1051,7 +1442,23 @@ func (d *Decoder) decodeMapFromMap(name string, dataVal reflect.Value, val refle
 }
 
 func (d *Decoder) decodeMapFromStruct(name string, dataVal reflect.Value, val reflect.Value, valMap reflect.Value) error {
+	if err := d.encodeStruct(name, dataVal, reflectMapDest{valMap}); err != nil {
+		return err
+	}
+
+	if val.CanAddr() {
+		val.Set(valMap)
+	}
+
+	return nil
+}
+
+// encodeStruct stores the fields of the struct dataVal into dest, in
+// field order.
+func (d *Decoder) encodeStruct(name string, dataVal reflect.Value, dest mapDest) error {
 	typ := dataVal.Type()
+	elemType := dest.elemType()
+	elemZero := reflect.Zero(elemType)
 	for i := 0; i < typ.NumField(); i++ {
 		// Get the StructField first since this is a cheap operation. If the
 		// field is unexported, then ignore it.
@@ -1063,10 +1470,10 @@ func (d *Decoder) decodeMapFromStruct(name string, dataVal reflect.Value, val re
 		// Next get the actual value of this field and verify it is assignable
 		// to the map value.
 		v := dataVal.Field(i)
-		if !v.Type().AssignableTo(valMap.Type().Elem()) {
+		if !v.Type().AssignableTo(elemType) {
 			return newDecodeError(
 				name+"."+f.Name,
-				fmt.Errorf("cannot assign type %q to map value field of type %q", v.Type(), valMap.Type().Elem()),
+				fmt.Errorf("cannot assign type %q to map value field of type %q", v.Type(), elemType),
 			)
 		}
 
@@ -1121,9 +1528,20 @@ func (d *Decoder) decodeMapFromStruct(name string, dataVal reflect.Value, val re
 						)
 					}
 
-					ptr := v.MapRange()
-					for ptr.Next() {
-						valMap.SetMapIndex(ptr.Key(), ptr.Value())
+					keys := v.MapKeys()
+					if dest.ordered() {
+						sortMapKeys(keys)
+					}
+					for _, k := range keys {
+						mv := v.MapIndex(k)
+						if d.shouldEncode(elemType) {
+							var err error
+							mv, err = d.encodeValue(name+"["+fmt.Sprint(k.Interface())+"]", mv, dest.ordered())
+							if err != nil {
+								return err
+							}
+						}
+						dest.set(k, mv)
 					}
 					continue
 				}
@@ -1138,54 +1556,592 @@ func (d *Decoder) decodeMapFromStruct(name string, dataVal reflect.Value, val re
 			keyName = tagValue
 		}
 
+		if encoding := tagOptionValue(tagValue, "encoding"); encoding != "" && !squash && !d.intermediate {
+			ev, err := encodeTaggedBytes(encoding, v)
+			if err != nil {
+				fieldName := f.Name
+				if name != "" {
+					fieldName = name + "." + f.Name
+				}
+
+				return newDecodeError(fieldName, err)
+			}
+			if ev.Type().AssignableTo(elemType) {
+				v = ev
+			}
+		}
+
+		if !squash && elemType.Kind() == reflect.Interface {
+			ev, ok, err := d.encodeScalar(keyName, v, elemZero)
+			if err != nil {
+				return err
+			}
+			if ok {
+				dest.set(reflect.ValueOf(keyName), ev)
+				continue
+			}
+			v = ev
+		}
+
 		switch v.Kind() {
 		// this is an embedded struct, so handle it differently
 		case reflect.Struct:
 			x := reflect.New(v.Type())
 			x.Elem().Set(v)
 
-			vType := valMap.Type()
-			vKeyType := vType.Key()
-			vElemType := vType.Elem()
-			mType := reflect.MapOf(vKeyType, vElemType)
-			vMap := reflect.MakeMap(mType)
-
-			// Creating a pointer to a map so that other methods can completely
-			// overwrite the map if need be (looking at you decodeMapFromMap). The
-			// indirection allows the underlying map to be settable (CanSet() == true)
-			// where as reflect.MakeMap returns an unsettable map.
-			addrVal := reflect.New(vMap.Type())
-			reflect.Indirect(addrVal).Set(vMap)
-
-			err := d.decode(keyName, x.Interface(), reflect.Indirect(addrVal))
+			// Decoding into a pointer to the nested map allows other methods
+			// to completely overwrite the map if need be (looking at you
+			// decodeMapFromMap).
+			nested := dest.newNested()
+			err := d.decode(keyName, x.Interface(), nested.Elem())
 			if err != nil {
 				return err
 			}
 
-			// the underlying map may have been completely overwritten so pull
-			// it indirectly out of the enclosing value.
-			vMap = reflect.Indirect(addrVal)
-
 			if squash {
-				for _, k := range vMap.MapKeys() {
-					valMap.SetMapIndex(k, vMap.MapIndex(k))
-				}
+				dest.merge(nested)
 			} else {
-				valMap.SetMapIndex(reflect.ValueOf(keyName), vMap)
+				dest.set(reflect.ValueOf(keyName), dest.nestedValue(nested))
 			}
 
 		default:
-			valMap.SetMapIndex(reflect.ValueOf(keyName), v)
+			if d.shouldEncode(elemType) {
+				var err error
+				v, err = d.encodeContents(keyName, v, dest.ordered())
+				if err != nil {
+					return err
+				}
+			}
+			dest.set(reflect.ValueOf(keyName), v)
 		}
 	}
 
-	if val.CanAddr() {
-		val.Set(valMap)
+	return nil
+}
+
+// mapDest is the destination of encodeStruct: either a Go map or an
+// OrderedMap.
+type mapDest interface {
+	// elemType is the type of the values stored into the destination.
+	elemType() reflect.Type
+
+	// ordered reports whether the destination preserves insertion order.
+	ordered() bool
+
+	// set stores value under key.
+	set(key, value reflect.Value)
+
+	// newNested returns a pointer to an empty destination for a nested
+	// struct.
+	newNested() reflect.Value
+
+	// nestedValue returns the value to store for a destination created by
+	// newNested.
+	nestedValue(nested reflect.Value) reflect.Value
+
+	// merge stores all the entries of a destination created by newNested.
+	merge(nested reflect.Value)
+}
+
+type reflectMapDest struct {
+	m reflect.Value
+}
+
+func (r reflectMapDest) elemType() reflect.Type { return r.m.Type().Elem() }
+
+func (r reflectMapDest) ordered() bool { return false }
+
+func (r reflectMapDest) set(key, value reflect.Value) { r.m.SetMapIndex(key, value) }
+
+func (r reflectMapDest) newNested() reflect.Value {
+	nested := reflect.New(r.m.Type())
+	nested.Elem().Set(reflect.MakeMap(r.m.Type()))
+
+	return nested
+}
+
+func (r reflectMapDest) nestedValue(nested reflect.Value) reflect.Value { return nested.Elem() }
+
+func (r reflectMapDest) merge(nested reflect.Value) {
+	iter := nested.Elem().MapRange()
+	for iter.Next() {
+		r.m.SetMapIndex(iter.Key(), iter.Value())
+	}
+}
+
+type orderedMapDest struct {
+	m *OrderedMap
+}
+
+func (o orderedMapDest) elemType() reflect.Type { return anyType }
+
+func (o orderedMapDest) ordered() bool { return true }
+
+func (o orderedMapDest) set(key, value reflect.Value) {
+	var v any
+	if value.IsValid() {
+		v = value.Interface()
 	}
 
+	o.m.Set(mapKeyString(key), v)
+}
+
+func (o orderedMapDest) newNested() reflect.Value { return reflect.New(orderedMapType) }
+
+func (o orderedMapDest) nestedValue(nested reflect.Value) reflect.Value { return nested }
+
+func (o orderedMapDest) merge(nested reflect.Value) {
+	om := nested.Interface().(*OrderedMap)
+	for _, k := range om.keys {
+		o.m.Set(k, om.values[k])
+	}
+}
+
+// decodeOrderedMap decodes a struct or a map into an OrderedMap. Struct
+// fields keep their declaration order and map entries are sorted by key.
+func (d *Decoder) decodeOrderedMap(name string, data any, val reflect.Value) error {
+	dataVal := reflect.ValueOf(data)
+	for dataVal.Kind() == reflect.Ptr {
+		dataVal = reflect.Indirect(dataVal)
+	}
+
+	// A nil pointer leaves an invalid value, which is unconvertible below.
+	if dataVal.IsValid() && dataVal.Type() == val.Type() {
+		val.Set(dataVal)
+		return nil
+	}
+
+	om := &OrderedMap{}
+	if !d.config.ZeroFields {
+		*om = val.Interface().(OrderedMap)
+	}
+
+	switch dataVal.Kind() {
+	case reflect.Struct:
+		if err := d.encodeStruct(name, dataVal, orderedMapDest{om}); err != nil {
+			return err
+		}
+
+	case reflect.Map:
+		keys := dataVal.MapKeys()
+		sortMapKeys(keys)
+		for _, k := range keys {
+			v := dataVal.MapIndex(k)
+			if d.shouldEncode(anyType) {
+				var err error
+				v, err = d.encodeValue(name+"["+mapKeyString(k)+"]", v, true)
+				if err != nil {
+					return err
+				}
+			}
+			orderedMapDest{om}.set(k, v)
+		}
+
+	default:
+		return newDecodeError(name, &UnconvertibleTypeError{
+			Expected: val,
+			Value:    data,
+		})
+	}
+
+	val.Set(reflect.ValueOf(om).Elem())
+
 	return nil
 }
 
+// mapKeyString returns the string form of a map key.
+func mapKeyString(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+
+	return fmt.Sprint(k.Interface())
+}
+
+// sortMapKeys sorts map keys by their string form.
+func sortMapKeys(keys []reflect.Value) {
+	sort.Slice(keys, func(i, j int) bool {
+		return mapKeyString(keys[i]) < mapKeyString(keys[j])
+	})
+}
+
+// shouldEncode reports whether values stored as elemType must be
+// recursively converted into maps, slices and scalars.
+func (d *Decoder) shouldEncode(elemType reflect.Type) bool {
+	return !d.intermediate && (d.config.RecursiveEncode || d.config.FlattenSeparator != "") &&
+		elemType.Kind() == reflect.Interface
+}
+
+// encodeValue converts v into its map representation: the EncodeHook and
+// the marshaler interfaces are applied first, then any struct found in v,
+// including structs nested in slices, arrays, maps and pointers, is
+// converted into a map[string]any.
+func (d *Decoder) encodeValue(name string, v reflect.Value, ordered bool) (reflect.Value, error) {
+	if !v.IsValid() {
+		return v, nil
+	}
+
+	v, ok, err := d.encodeScalar(name, v, reflect.Zero(anyType))
+	if ok || err != nil {
+		return v, err
+	}
+
+	return d.encodeContents(name, v, ordered)
+}
+
+// encodeScalar applies the EncodeHook and the marshaler interfaces to v.
+// It reports whether v was converted into its final map representation.
+// Otherwise v, or the value of the same type returned by the hook, is
+// returned for its contents to be converted.
+func (d *Decoder) encodeScalar(name string, v, to reflect.Value) (reflect.Value, bool, error) {
+	if d.cachedEncodeHook != nil && !d.intermediate {
+		from := v
+		if from.Kind() == reflect.Interface && !from.IsNil() {
+			from = from.Elem()
+		}
+
+		// Nil interfaces have nothing to be converted.
+		if from.Kind() != reflect.Interface {
+			out, err := d.cachedEncodeHook(name, from, to)
+			if err != nil {
+				return reflect.Value{}, false, newDecodeError(name, err)
+			}
+
+			outVal, ok := out.(reflect.Value)
+			if !ok {
+				outVal = reflect.ValueOf(out)
+			}
+			if !outVal.IsValid() {
+				return reflect.Zero(anyType), true, nil
+			}
+			if outVal.Type() != from.Type() {
+				return outVal, true, nil
+			}
+			v = outVal
+		}
+	}
+
+	return d.marshalValue(name, v)
+}
+
+// encodeContents converts any struct found in v, including structs nested
+// in slices, arrays, maps and pointers, into a map[string]any, or into an
+// *OrderedMap if ordered is set. Values which do not need to be converted
+// are returned unchanged.
+func (d *Decoder) encodeContents(name string, v reflect.Value, ordered bool) (reflect.Value, error) {
+	if !v.IsValid() || d.cachedEncodeHook == nil && !d.mayNeedEncoding(v.Type()) {
+		return v, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if !v.IsNil() {
+			visit, err := d.enterEncode(name, v)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			defer d.leaveEncode(visit)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(anyType), nil
+		}
+
+		return d.encodeValue(name, v.Elem(), ordered)
+
+	case reflect.Struct:
+		if ordered {
+			om := reflect.New(orderedMapType)
+			if err := d.decode(name, v.Interface(), om.Elem()); err != nil {
+				return reflect.Value{}, err
+			}
+
+			return om, nil
+		}
+
+		mval := reflect.New(mapStringAnyType).Elem()
+		mval.Set(reflect.MakeMap(mapStringAnyType))
+		if err := d.decode(name, v.Interface(), mval); err != nil {
+			return reflect.Value{}, err
+		}
+
+		return mval, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return reflect.Zero(reflect.SliceOf(anyType)), nil
+		}
+
+		out := reflect.MakeSlice(reflect.SliceOf(anyType), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			ev, err := d.encodeValue(name+"["+strconv.Itoa(i)+"]", v.Index(i), ordered)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if ev.IsValid() {
+				out.Index(i).Set(ev)
+			}
+		}
+
+		return out, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(reflect.MapOf(v.Type().Key(), anyType)), nil
+		}
+
+		if ordered {
+			om := reflect.New(orderedMapType)
+			if err := d.decodeOrderedMap(name, v.Interface(), om.Elem()); err != nil {
+				return reflect.Value{}, err
+			}
+
+			return om, nil
+		}
+
+		out := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), anyType), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			ev, err := d.encodeValue(name+"["+fmt.Sprint(iter.Key().Interface())+"]", iter.Value(), ordered)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if !ev.IsValid() {
+				ev = reflect.Zero(anyType)
+			}
+			out.SetMapIndex(iter.Key(), ev)
+		}
+
+		return out, nil
+	}
+
+	return v, nil
+}
+
+// encodeCycleDepth is the depth past which encodeContents checks for
+// cycles, so that the common shallow values are encoded without the cost
+// of tracking them.
+const encodeCycleDepth = 1000
+
+// encodeVisit identifies a pointer, map or slice followed by
+// encodeContents. Slices are identified by their length too, since a
+// slice and its subslices share their first element.
+type encodeVisit struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// enterEncode records that encodeContents follows the non-nil pointer,
+// map or slice v, and returns an error if v is already being followed.
+func (d *Decoder) enterEncode(name string, v reflect.Value) (encodeVisit, error) {
+	d.encodeDepth++
+	if d.encodeDepth <= encodeCycleDepth {
+		return encodeVisit{}, nil
+	}
+
+	visit := encodeVisit{typ: v.Type(), ptr: v.Pointer()}
+	if v.Kind() == reflect.Slice {
+		visit.len = v.Len()
+	}
+	if _, ok := d.encodeVisits[visit]; ok {
+		d.encodeDepth--
+		return encodeVisit{}, newDecodeError(name, fmt.Errorf("encountered a cycle via %s", v.Type()))
+	}
+
+	if d.encodeVisits == nil {
+		d.encodeVisits = make(map[encodeVisit]struct{})
+	}
+	d.encodeVisits[visit] = struct{}{}
+
+	return visit, nil
+}
+
+// leaveEncode undoes enterEncode once v has been encoded.
+func (d *Decoder) leaveEncode(visit encodeVisit) {
+	d.encodeDepth--
+	if visit.typ != nil {
+		delete(d.encodeVisits, visit)
+	}
+}
+
+// marshalValue converts v using its MarshalMapstructure method or, if
+// enabled in the config, its MarshalText or String method. It reports
+// whether v was converted.
+func (d *Decoder) marshalValue(name string, v reflect.Value) (reflect.Value, bool, error) {
+	target := v
+	if target.Kind() == reflect.Interface {
+		if target.IsNil() {
+			return v, false, nil
+		}
+		target = target.Elem()
+	}
+	if target.Kind() == reflect.Ptr && target.IsNil() {
+		return v, false, nil
+	}
+
+	if !target.CanInterface() {
+		return v, false, nil
+	}
+	traits := typeEncodeTraits(target.Type()) & d.marshalerTraits()
+	if traits == 0 {
+		return v, false, nil
+	}
+
+	// Methods with a pointer receiver are only available on addressable
+	// values, so non-addressable values are copied.
+	if target.Kind() != reflect.Ptr {
+		target = addressable(target).Addr()
+	}
+	data := target.Interface()
+
+	if m, ok := data.(Marshaler); ok && traits&traitMarshaler != 0 {
+		out, err := m.MarshalMapstructure()
+		if err != nil {
+			return reflect.Value{}, false, newDecodeError(name, err)
+		}
+		if out == nil {
+			return reflect.Zero(anyType), true, nil
+		}
+
+		return reflect.ValueOf(out), true, nil
+	}
+
+	if m, ok := data.(encoding.TextMarshaler); ok && traits&traitTextMarshaler != 0 {
+		text, err := m.MarshalText()
+		if err != nil {
+			return reflect.Value{}, false, newDecodeError(name, err)
+		}
+
+		return reflect.ValueOf(string(text)), true, nil
+	}
+
+	if m, ok := data.(fmt.Stringer); ok && traits&traitStringer != 0 {
+		return reflect.ValueOf(m.String()), true, nil
+	}
+
+	if out, ok := encodeBig(target); ok {
+		return out, true, nil
+	}
+
+	return v, false, nil
+}
+
+// mayNeedEncoding reports whether a value of type t can hold a struct or a
+// marshaler, either directly or through pointers, interfaces, slices, arrays
+// and maps.
+func (d *Decoder) mayNeedEncoding(t reflect.Type) bool {
+	return heldEncodeTraits(t)&(d.marshalerTraits()|traitStruct) != 0
+}
+
+// marshalerTraits returns the traits of the types converted by
+// marshalValue with the options of the config.
+func (d *Decoder) marshalerTraits() encodeTraits {
+	// Big values are still copied into the map used to decode a struct
+	// into another struct: their fields are unexported, so converting them
+	// into maps would lose them.
+	if d.intermediate {
+		return traitBig
+	}
+
+	traits := traitBig | traitMarshaler
+	if d.config.EncodeTextMarshaler {
+		traits |= traitTextMarshaler
+	}
+	if d.config.EncodeStringer {
+		traits |= traitStringer
+	}
+
+	return traits
+}
+
+// encodeTraits describes how values of a type may be encoded, independently
+// of the config, so that it can be cached for all Decoders.
+type encodeTraits uint8
+
+const (
+	traitBig encodeTraits = 1 << iota
+	traitMarshaler
+	traitTextMarshaler
+	traitStringer
+
+	// traitStruct is set by heldEncodeTraits for types that can hold a
+	// struct.
+	traitStruct
+)
+
+var (
+	typeEncodeTraitsCache sync.Map // map[reflect.Type]encodeTraits
+	heldEncodeTraitsCache sync.Map // map[reflect.Type]encodeTraits
+)
+
+// typeEncodeTraits returns the marshaler traits of t.
+func typeEncodeTraits(t reflect.Type) encodeTraits {
+	if traits, ok := typeEncodeTraitsCache.Load(t); ok {
+		return traits.(encodeTraits)
+	}
+
+	pt := reflect.PtrTo(t)
+	implements := func(iface reflect.Type) bool {
+		return t.Implements(iface) || pt.Implements(iface)
+	}
+
+	var traits encodeTraits
+	if isBigType(t) {
+		traits |= traitBig
+	}
+	if implements(marshalerType) {
+		traits |= traitMarshaler
+	}
+	if implements(textMarshalerType) {
+		traits |= traitTextMarshaler
+	}
+	if implements(stringerType) {
+		traits |= traitStringer
+	}
+
+	typeEncodeTraitsCache.Store(t, traits)
+
+	return traits
+}
+
+// heldEncodeTraits returns the traits of the values a value of type t can
+// hold, either directly or through pointers, interfaces, slices, arrays and
+// maps.
+func heldEncodeTraits(t reflect.Type) encodeTraits {
+	if traits, ok := heldEncodeTraitsCache.Load(t); ok {
+		return traits.(encodeTraits)
+	}
+
+	var traits encodeTraits
+	seen := make(map[reflect.Type]struct{})
+	for elem := t; ; elem = elem.Elem() {
+		traits |= typeEncodeTraits(elem)
+
+		kind := elem.Kind()
+		if kind == reflect.Struct || kind == reflect.Interface {
+			traits |= traitStruct
+			break
+		}
+		if kind != reflect.Ptr && kind != reflect.Slice && kind != reflect.Array && kind != reflect.Map {
+			break
+		}
+
+		// Recursive types such as "type T []T" cannot hold a struct.
+		if _, ok := seen[elem]; ok {
+			break
+		}
+		seen[elem] = struct{}{}
+	}
+
+	heldEncodeTraitsCache.Store(t, traits)
+
+	return traits
+}
+
 func (d *Decoder) decodePtr(name string, data any, val reflect.Value) (bool, error) {
 	// If the input data is nil, then we want to just set the output
 	// pointer to be nil as well.
@@ -1413,7 +2369,11 @@ func (d *Decoder) decodeStruct(name string, data any, val reflect.Value) error {
 		addrVal := reflect.New(mval.Type())
 
 		reflect.Indirect(addrVal).Set(mval)
-		if err := d.decodeMapFromStruct(name, dataVal, reflect.Indirect(addrVal), mval); err != nil {
+		intermediate := d.intermediate
+		d.intermediate = true
+		err := d.decodeMapFromStruct(name, dataVal, reflect.Indirect(addrVal), mval)
+		d.intermediate = intermediate
+		if err != nil {
 			return err
 		}
 
@@ -1542,6 +2502,10 @@ func (d *Decoder) decodeStructFromMap(name string, dataVal, val reflect.Value) e
 		if tagValue == "" && d.config.IgnoreUntaggedFields {
 			continue
 		}
+		opts := fieldOptions{
+			encoding: tagOptionValue(tagValue, "encoding"),
+			json:     hasTagOption(tagValue, "json"),
+		}
 		tagValue = strings.SplitN(tagValue, ",", 2)[0]
 		if tagValue != "" {
 			fieldName = tagValue
@@ -1596,7 +2560,8 @@ func (d *Decoder) decodeStructFromMap(name string, dataVal, val reflect.Value) e
 			fieldName = name + "." + fieldName
 		}
 
-		if err := d.decode(fieldName, rawMapVal.Interface(), fieldValue); err != nil {
+		input := rawMapVal.Interface()
+		if err := d.decodeField(fieldName, input, fieldValue, opts); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -1635,7 +2600,7 @@ func (d *Decoder) decodeStructFromMap(name string, dataVal, val reflect.Value) e
 		}
 		errs = append(errs, newDecodeError(
 			errorName,
-			fmt.Errorf("has invalid keys: %s", strings.Join(keys, ", ")),
+			&UnusedKeysError{Keys: joinPaths(name, keys), names: keys},
 		))
 	}
 
@@ -1648,7 +2613,7 @@ func (d *Decoder) decodeStructFromMap(name string, dataVal, val reflect.Value) e
 
 		errs = append(errs, newDecodeError(
 			name,
-			fmt.Errorf("has unset fields: %s", strings.Join(keys, ", ")),
+			&UnsetFieldsError{Fields: joinPaths(name, keys), names: keys},
 		))
 	}
 
@@ -1676,9 +2641,69 @@ func (d *Decoder) decodeStructFromMap(name string, dataVal, val reflect.Value) e
 		}
 	}
 
+	return d.afterDecode(name, val)
+}
+
+// joinPaths returns the paths of the given keys of the struct at name.
+func joinPaths(name string, keys []string) []string {
+	if name == "" {
+		return keys
+	}
+
+	paths := make([]string, len(keys))
+	for i, key := range keys {
+		paths[i] = name + "." + key
+	}
+
+	return paths
+}
+
+// afterDecode runs the AfterDecode hooks and, if enabled, the Validate
+// method of a struct whose fields have all been decoded successfully.
+func (d *Decoder) afterDecode(name string, val reflect.Value) error {
+	// Improve error message when name is empty by showing the target struct type
+	errorName := name
+	if errorName == "" {
+		errorName = val.Type().String()
+	}
+
+	for _, hook := range d.config.AfterDecode {
+		if err := hook(val); err != nil {
+			return newDecodeError(errorName, err)
+		}
+	}
+
+	if !d.config.Validate {
+		return nil
+	}
+
+	target := val
+	if val.CanAddr() {
+		target = val.Addr()
+	}
+	if !target.CanInterface() {
+		return nil
+	}
+	if v, ok := target.Interface().(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return newDecodeError(errorName, err)
+		}
+	}
+
 	return nil
 }
 
+var (
+	anyType                = reflect.TypeOf((*any)(nil)).Elem()
+	mapStringAnyType       = reflect.TypeOf((map[string]any)(nil))
+	marshalerType          = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType        = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	contextUnmarshalerType = reflect.TypeOf((*ContextUnmarshaler)(nil)).Elem()
+	textMarshalerType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType           = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	orderedMapType         = reflect.TypeOf(OrderedMap{})
+)
+
 func isEmptyValue(v reflect.Value) bool {
 	switch getKind(v) {
 	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
@@ -2,6 +2,7 @@ package mapstructure
 
 import (
 	"encoding/json"
+	"strconv"
 	"testing"
 )
 
@@ -309,3 +310,72 @@ func Benchmark_DecodeWithRemainingFields(b *testing.B) {
 		_ = Decode(&person, &result)
 	}
 }
+
+func Benchmark_DecodeBasicTypeHook(b *testing.B) {
+	input := map[string]any{
+		"vstring": "foo",
+		"vint":    "42",
+		"vuint":   "42",
+		"vbool":   "true",
+		"vfloat":  "42.42",
+	}
+
+	config := &DecoderConfig{
+		DecodeHook: StringToBasicTypeHookFunc(),
+		Result:     &Basic{},
+	}
+	decoder, _ := NewDecoder(config)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoder.Decode(input)
+	}
+}
+
+func Benchmark_DecodeBasicTypeConverters(b *testing.B) {
+	input := map[string]any{
+		"vstring": "foo",
+		"vint":    "42",
+		"vuint":   "42",
+		"vbool":   "true",
+		"vfloat":  "42.42",
+	}
+
+	var c Converters
+	Register(&c, func(s string) (int, error) { return strconv.Atoi(s) })
+	Register(&c, func(s string) (uint, error) {
+		u, err := strconv.ParseUint(s, 0, 0)
+		return uint(u), err
+	})
+	Register(&c, strconv.ParseBool)
+	Register(&c, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+
+	config := &DecoderConfig{
+		Converters: &c,
+		Result:     &Basic{},
+	}
+	decoder, _ := NewDecoder(config)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decoder.Decode(input)
+	}
+}
+
+func Benchmark_EncodeStruct(b *testing.B) {
+	input := Basic{
+		Vstring:   "foo",
+		Vint:      42,
+		Vuint:     42,
+		Vbool:     true,
+		Vfloat:    42.42,
+		Vdata:     42,
+		VjsonInt:  1234,
+		VjsonUint: 1234,
+	}
+
+	for i := 0; i < b.N; i++ {
+		var result map[string]any
+		Decode(input, &result)
+	}
+}
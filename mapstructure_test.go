@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -1714,6 +1716,104 @@ func TestDecoder_ErrorUnset(t *testing.T) {
 	}
 }
 
+func TestDecoder_ErrorUnusedUnsetTypes(t *testing.T) {
+	t.Parallel()
+
+	type TLS struct {
+		Cert string
+		Key  string
+	}
+
+	type Server struct {
+		Host string
+		TLS  TLS `mapstructure:"tls"`
+	}
+
+	type Config struct {
+		Name    string
+		Servers []Server
+	}
+
+	input := map[string]any{
+		"name":  "app",
+		"extra": true,
+		"servers": []any{
+			map[string]any{
+				"host": "a",
+				"port": 80,
+				"tls":  map[string]any{"cert": "a.crt", "ca": "ca.crt", "chain": "c.crt"},
+			},
+		},
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		ErrorUnused: true,
+		ErrorUnset:  true,
+		Result:      &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(input)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, expected := range []string{
+		"'mapstructure.Config' has invalid keys: extra",
+		"'Servers[0]' has invalid keys: port",
+		"'Servers[0].tls' has invalid keys: ca, chain",
+		"'Servers[0].tls' has unset fields: Key",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got: %s", expected, err)
+		}
+	}
+
+	var unused []string
+	var unset []string
+	var walk func(err error)
+	walk = func(err error) {
+		switch err := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, err := range err.Unwrap() {
+				walk(err)
+			}
+		case *UnusedKeysError:
+			unused = append(unused, err.Keys...)
+		case *UnsetFieldsError:
+			unset = append(unset, err.Fields...)
+		case interface{ Unwrap() error }:
+			walk(err.Unwrap())
+		}
+	}
+	walk(err)
+
+	sort.Strings(unused)
+	if expected := []string{"Servers[0].port", "Servers[0].tls.ca", "Servers[0].tls.chain", "extra"}; !reflect.DeepEqual(unused, expected) {
+		t.Errorf("expected unused keys %q, got %q", expected, unused)
+	}
+	if expected := []string{"Servers[0].tls.Key"}; !reflect.DeepEqual(unset, expected) {
+		t.Errorf("expected unset fields %q, got %q", expected, unset)
+	}
+
+	var uerr *UnusedKeysError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected UnusedKeysError, got %T", err)
+	}
+
+	var merr Error
+	if !errors.As(err, &merr) {
+		t.Fatalf("expected Error, got %T", err)
+	}
+
+	if msg := (&UnsetFieldsError{Fields: []string{"a.b"}}).Error(); msg != "has unset fields: a.b" {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
 func TestDecoder_ErrorUnset_AllowUnsetPointer(t *testing.T) {
 	t.Parallel()
 
@@ -3678,3 +3778,699 @@ func TestDecoder_MapFieldName(t *testing.T) {
 		t.Fatal("expected Username to exist")
 	}
 }
+
+func TestDecoder_RecursiveEncode(t *testing.T) {
+	t.Parallel()
+
+	type Backend struct {
+		Host string
+		Port int
+	}
+
+	type Server struct {
+		Name     string
+		Backends []Backend
+	}
+
+	type Config struct {
+		Servers  []Server
+		ByName   map[string]*Backend
+		Primary  *Backend
+		Fallback *Backend
+		Any      any
+		Tags     []string
+		Fixed    [1]Backend
+		Extra    map[string]any `mapstructure:",remain"`
+	}
+
+	input := Config{
+		Servers: []Server{
+			{Name: "a", Backends: []Backend{{Host: "h1", Port: 1}}},
+		},
+		ByName:  map[string]*Backend{"b": {Host: "h2", Port: 2}},
+		Primary: &Backend{Host: "h3", Port: 3},
+		Any:     Backend{Host: "h4", Port: 4},
+		Tags:    []string{"x"},
+		Fixed:   [1]Backend{{Host: "h5", Port: 5}},
+		Extra:   map[string]any{"nested": []any{Backend{Host: "h6", Port: 6}}},
+	}
+
+	var result map[string]any
+	decoder, err := NewDecoder(&DecoderConfig{
+		RecursiveEncode: true,
+		Result:          &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]any{
+		"Servers": []any{
+			map[string]any{
+				"Name": "a",
+				"Backends": []any{
+					map[string]any{"Host": "h1", "Port": 1},
+				},
+			},
+		},
+		"ByName": map[string]any{
+			"b": map[string]any{"Host": "h2", "Port": 2},
+		},
+		"Primary":  map[string]any{"Host": "h3", "Port": 3},
+		"Fallback": nil,
+		"Any":      map[string]any{"Host": "h4", "Port": 4},
+		"Tags":     []string{"x"},
+		"Fixed": []any{
+			map[string]any{"Host": "h5", "Port": 5},
+		},
+		"nested": []any{
+			map[string]any{"Host": "h6", "Port": 6},
+		},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", expected, result)
+	}
+}
+
+func TestDecoder_RecursiveEncodeCycle(t *testing.T) {
+	t.Parallel()
+
+	type Node struct {
+		Name string
+		Next *Node
+	}
+
+	node := &Node{Name: "a"}
+	node.Next = node
+
+	loop := map[string]any{}
+	loop["self"] = loop
+
+	for _, input := range []any{struct{ N *Node }{node}, struct{ M map[string]any }{loop}} {
+		var result map[string]any
+		decoder, err := NewDecoder(&DecoderConfig{
+			RecursiveEncode: true,
+			Result:          &result,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		err = decoder.Decode(input)
+		if err == nil || !strings.Contains(err.Error(), "encountered a cycle via") {
+			t.Fatalf("expected cycle error, got %.200v", err)
+		}
+
+		var derr *DecodeError
+		if !errors.As(err, &derr) {
+			t.Fatalf("expected DecodeError, got %T", err)
+		}
+	}
+
+	// Values shared without a cycle are encoded wherever they appear.
+	shared := &Node{Name: "b"}
+	var result map[string]any
+	decoder, err := NewDecoder(&DecoderConfig{
+		RecursiveEncode: true,
+		Result:          &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(struct{ A, B *Node }{shared, shared}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := map[string]any{"Name": "b", "Next": nil}
+	if !reflect.DeepEqual(result["A"], expected) || !reflect.DeepEqual(result["B"], expected) {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+}
+
+func TestDecoder_RecursiveEncodeDisabled(t *testing.T) {
+	t.Parallel()
+
+	type Backend struct {
+		Host string
+	}
+
+	type Config struct {
+		Backends []Backend
+	}
+
+	input := Config{Backends: []Backend{{Host: "h1"}}}
+
+	var result map[string]any
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(result["Backends"], input.Backends) {
+		t.Fatalf("expected raw value to be copied, got %#v", result["Backends"])
+	}
+}
+
+type testEndpoint struct {
+	Host string
+	Port int
+}
+
+func (e testEndpoint) MarshalMapstructure() (any, error) {
+	if e.Port < 0 {
+		return nil, errors.New("invalid port")
+	}
+
+	return e.Host + ":" + strconv.Itoa(e.Port), nil
+}
+
+func (e *testEndpoint) UnmarshalMapstructure(input any) error {
+	switch v := input.(type) {
+	case string:
+		host, port, ok := strings.Cut(v, ":")
+		if !ok {
+			return errors.New("missing port")
+		}
+
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return err
+		}
+
+		e.Host, e.Port = host, p
+
+		return nil
+	default:
+		type plain testEndpoint
+
+		return Decode(input, (*plain)(e))
+	}
+}
+
+type testLevel int
+
+func (l testLevel) String() string {
+	return [...]string{"debug", "info"}[l]
+}
+
+func TestDecoder_EncodeMarshalers(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Endpoint  testEndpoint
+		Fallback  *testEndpoint
+		Created   time.Time
+		Addr      net.IP
+		Level     testLevel
+		Endpoints []testEndpoint
+	}
+
+	input := Config{
+		Endpoint:  testEndpoint{Host: "localhost", Port: 80},
+		Created:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Addr:      net.IPv4(127, 0, 0, 1),
+		Level:     1,
+		Endpoints: []testEndpoint{{Host: "a", Port: 1}},
+	}
+
+	var result map[string]any
+	decoder, err := NewDecoder(&DecoderConfig{
+		RecursiveEncode:     true,
+		EncodeTextMarshaler: true,
+		EncodeStringer:      true,
+		Result:              &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]any{
+		"Endpoint":  "localhost:80",
+		"Fallback":  nil,
+		"Created":   "2024-01-02T03:04:05Z",
+		"Addr":      "127.0.0.1",
+		"Level":     "info",
+		"Endpoints": []any{"a:1"},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", expected, result)
+	}
+}
+
+func TestDecoder_EncodeMarshalersDisabled(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Endpoint testEndpoint
+		Level    testLevel
+	}
+
+	var result map[string]any
+	if err := Decode(Config{Endpoint: testEndpoint{Host: "h", Port: 1}}, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Marshaler is always honored, the other interfaces are opt-in.
+	expected := map[string]any{
+		"Endpoint": "h:1",
+		"Level":    testLevel(0),
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", expected, result)
+	}
+}
+
+func TestDecoder_EncodeMarshalersStructToStruct(t *testing.T) {
+	t.Parallel()
+
+	type A struct {
+		Addr  net.IP
+		D     time.Duration
+		Level testLevel
+		B     []byte `mapstructure:",encoding=base64"`
+	}
+
+	type B struct {
+		Addr  net.IP
+		D     time.Duration
+		Level testLevel
+		B     []byte `mapstructure:",encoding=base64"`
+	}
+
+	input := A{
+		Addr:  net.IPv4(127, 0, 0, 1),
+		D:     5 * time.Second,
+		Level: 1,
+		B:     []byte("data"),
+	}
+
+	var result B
+	decoder, err := NewDecoder(&DecoderConfig{
+		EncodeTextMarshaler: true,
+		EncodeStringer:      true,
+		EncodeHook: func(from, to reflect.Type, data any) (any, error) {
+			if from == reflect.TypeOf(time.Duration(0)) {
+				return data.(time.Duration).String(), nil
+			}
+
+			return data, nil
+		},
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The map built to decode a struct into another struct is not the
+	// result, so the encode options do not apply to it.
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(result, B(input)) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", input, result)
+	}
+}
+
+func TestDecoder_EncodeMarshalerError(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Endpoint testEndpoint
+	}
+
+	var result map[string]any
+	err := Decode(Config{Endpoint: testEndpoint{Port: -1}}, &result)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var derr *DecodeError
+	if !errors.As(err, &derr) || derr.Name() != "Endpoint" {
+		t.Fatalf("expected DecodeError for 'Endpoint', got %#v", err)
+	}
+}
+
+func TestDecoder_Unmarshaler(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Short    testEndpoint
+		Full     testEndpoint
+		Optional *testEndpoint
+	}
+
+	input := map[string]any{
+		"short":    "localhost:80",
+		"full":     map[string]any{"host": "example.com", "port": 443},
+		"optional": "other:8080",
+	}
+
+	var result Config
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{
+		Short:    testEndpoint{Host: "localhost", Port: 80},
+		Full:     testEndpoint{Host: "example.com", Port: 443},
+		Optional: &testEndpoint{Host: "other", Port: 8080},
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", expected, result)
+	}
+
+	err := Decode(map[string]any{"short": "localhost"}, &result)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	if !strings.Contains(err.Error(), "'Short' missing port") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestDecoder_MarshalerRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Endpoint testEndpoint
+	}
+
+	input := Config{Endpoint: testEndpoint{Host: "localhost", Port: 80}}
+
+	var encoded map[string]any
+	if err := Decode(input, &encoded); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var result Config
+	if err := Decode(encoded, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(result, input) {
+		t.Fatalf("expected %#v, got %#v", input, result)
+	}
+}
+
+type testRange struct {
+	Min int
+	Max int
+}
+
+func (r *testRange) Validate() error {
+	if r.Min > r.Max {
+		return errors.New("min must not exceed max")
+	}
+
+	return nil
+}
+
+type testLimits struct {
+	Range   testRange
+	Ranges  []testRange
+	visited *[]string
+}
+
+func (l testLimits) Validate() error {
+	if l.visited != nil {
+		*l.visited = append(*l.visited, "limits")
+	}
+
+	if l.Range.Max > 100 {
+		return errors.New("range too large")
+	}
+
+	return nil
+}
+
+func TestDecoder_Validate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		input map[string]any
+		err   string
+	}{
+		{
+			"valid",
+			map[string]any{
+				"range":  map[string]any{"min": 1, "max": 2},
+				"ranges": []any{map[string]any{"min": 1, "max": 1}},
+			},
+			"",
+		},
+		{
+			"invalid nested struct",
+			map[string]any{
+				"range": map[string]any{"min": 3, "max": 2},
+			},
+			"'Range' min must not exceed max",
+		},
+		{
+			"invalid slice element",
+			map[string]any{
+				"ranges": []any{map[string]any{"min": 3, "max": 2}},
+			},
+			"'Ranges[0]' min must not exceed max",
+		},
+		{
+			"invalid root",
+			map[string]any{
+				"range": map[string]any{"min": 1, "max": 200},
+			},
+			"'mapstructure.testLimits' range too large",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var result testLimits
+			decoder, err := NewDecoder(&DecoderConfig{
+				Validate: true,
+				Result:   &result,
+			})
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			err = decoder.Decode(tc.input)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestDecoder_ValidateDisabled(t *testing.T) {
+	t.Parallel()
+
+	// Structs are not validated unless asked, so that they can be
+	// validated once decoding is complete.
+	var result testLimits
+	input := map[string]any{"range": map[string]any{"min": 3, "max": 200}}
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if result.Range.Min != 3 || result.Range.Max != 200 {
+		t.Fatalf("unexpected result: %#v", result)
+	}
+}
+
+func TestDecoder_AfterDecode(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Name string
+	}
+
+	type Outer struct {
+		Inner Inner
+		Names []string
+	}
+
+	var visited []string
+	result := testLimits{visited: &visited}
+	var outer Outer
+
+	normalize := func(val reflect.Value) error {
+		visited = append(visited, val.Type().Name())
+
+		if inner, ok := val.Addr().Interface().(*Inner); ok {
+			if inner.Name == "" {
+				return errors.New("name is required")
+			}
+			inner.Name = strings.ToLower(inner.Name)
+		}
+
+		return nil
+	}
+
+	decoder, err := NewDecoder(&DecoderConfig{
+		AfterDecode: []AfterDecodeHookFunc{normalize},
+		Result:      &outer,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(map[string]any{"inner": map[string]any{"name": "ABC"}}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if outer.Inner.Name != "abc" {
+		t.Fatalf("expected normalized name, got %q", outer.Inner.Name)
+	}
+
+	// Nested structs are processed first.
+	if !reflect.DeepEqual(visited, []string{"Inner", "Outer"}) {
+		t.Fatalf("unexpected order: %v", visited)
+	}
+
+	// Hooks run before Validate.
+	visited = nil
+	decoder, err = NewDecoder(&DecoderConfig{
+		AfterDecode: []AfterDecodeHookFunc{normalize},
+		Validate:    true,
+		Result:      &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(map[string]any{}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(visited, []string{"testLimits", "limits"}) {
+		t.Fatalf("unexpected order: %v", visited)
+	}
+
+	decoder, err = NewDecoder(&DecoderConfig{
+		AfterDecode: []AfterDecodeHookFunc{normalize},
+		Result:      &Outer{},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(map[string]any{"inner": map[string]any{}})
+	if err == nil || !strings.Contains(err.Error(), "'Inner' name is required") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type testContextEndpoint struct {
+	Host string
+	Port int
+	TLS  bool
+}
+
+func (e *testContextEndpoint) UnmarshalMapstructureContext(ctx *DecodeContext, input any) error {
+	if s, ok := input.(string); ok {
+		host, port, ok := strings.Cut(s, ":")
+		if !ok {
+			return errors.New("missing port")
+		}
+
+		e.Host = host
+		return ctx.DecodeField("port", port, reflect.ValueOf(&e.Port).Elem())
+	}
+
+	type plain testContextEndpoint
+	return ctx.Decode(input, reflect.ValueOf((*plain)(e)).Elem())
+}
+
+func TestDecoder_ContextUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Short     testContextEndpoint
+		Full      testContextEndpoint
+		Endpoints []testContextEndpoint
+	}
+
+	input := map[string]any{
+		"short": "localhost:80",
+		"full": map[string]any{
+			"host": "example.com",
+			"port": "443",
+			"tls":  "true",
+		},
+		"endpoints": []any{"a:1"},
+	}
+
+	var result Config
+	var md Metadata
+	decoder, err := NewDecoder(&DecoderConfig{
+		WeaklyTypedInput: true,
+		Metadata:         &md,
+		Result:           &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{
+		Short:     testContextEndpoint{Host: "localhost", Port: 80},
+		Full:      testContextEndpoint{Host: "example.com", Port: 443, TLS: true},
+		Endpoints: []testContextEndpoint{{Host: "a", Port: 1}},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected:\n%#v\ngot:\n%#v", expected, result)
+	}
+
+	sort.Strings(md.Keys)
+	expectedKeys := []string{
+		"Endpoints", "Endpoints[0]", "Endpoints[0].port",
+		"Full", "Full.Host", "Full.Port", "Full.TLS",
+		"Short", "Short.port",
+	}
+	if !reflect.DeepEqual(md.Keys, expectedKeys) {
+		t.Fatalf("expected keys %v, got %v", expectedKeys, md.Keys)
+	}
+
+	cases := []struct {
+		input map[string]any
+		err   string
+	}{
+		{map[string]any{"short": "localhost"}, "'Short' missing port"},
+		{map[string]any{"short": "localhost:http"}, "'Short.port' cannot parse value as 'int'"},
+		{map[string]any{"full": map[string]any{"port": "http"}}, "'Full.Port' cannot parse value as 'int'"},
+	}
+
+	for _, tc := range cases {
+		var result Config
+		err := WeakDecode(tc.input, &result)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("expected error %q, got %v", tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package mapstructure
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// OrderedMap is a map with string keys that remembers the order in which
+// keys were first inserted. The zero value is an empty map ready to use.
+//
+// Decoding a struct into an OrderedMap keeps the order of the struct
+// fields: squashed structs are inserted at the position of the embedded
+// field and the entries of a ",remain" field are inserted at the position
+// of that field, sorted by key. Decoding a map into an OrderedMap inserts
+// its entries sorted by key. If RecursiveEncode is set, nested structs and
+// maps are decoded into *OrderedMap values as well.
+//
+//	var result OrderedMap
+//	err := Decode(config, &result)
+type OrderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+// Set sets the value for key. A new key is appended at the end, an
+// existing key keeps its position.
+func (m *OrderedMap) Set(key string, value any) {
+	if m.values == nil {
+		m.values = make(map[string]any)
+	}
+
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get returns the value for key and whether it was found.
+func (m *OrderedMap) Get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Delete removes key from the map.
+func (m *OrderedMap) Delete(key string) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+
+	delete(m.values, key)
+	for i, k := range m.keys {
+		if k == key {
+			m.keys = append(m.keys[:i:i], m.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys of the map in insertion order.
+func (m *OrderedMap) Keys() []string {
+	keys := make([]string, len(m.keys))
+	copy(keys, m.keys)
+
+	return keys
+}
+
+// Len returns the number of entries in the map.
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+// MarshalJSON encodes the map as a JSON object with keys in insertion order.
+func (m OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		value, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
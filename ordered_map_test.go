@@ -0,0 +1,139 @@
+package mapstructure
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestOrderedMap(t *testing.T) {
+	t.Parallel()
+
+	var m OrderedMap
+	m.Set("b", 1)
+	m.Set("a", 2)
+	m.Set("c", 3)
+	m.Set("b", 4)
+
+	if !reflect.DeepEqual(m.Keys(), []string{"b", "a", "c"}) {
+		t.Fatalf("unexpected keys: %v", m.Keys())
+	}
+
+	if v, ok := m.Get("b"); !ok || v != 4 {
+		t.Fatalf("unexpected value for b: %v", v)
+	}
+
+	m.Delete("a")
+	m.Delete("missing")
+
+	if m.Len() != 2 || !reflect.DeepEqual(m.Keys(), []string{"b", "c"}) {
+		t.Fatalf("unexpected keys after delete: %v", m.Keys())
+	}
+
+	if _, ok := m.Get("a"); ok {
+		t.Fatal("expected a to be deleted")
+	}
+}
+
+func TestDecode_OrderedMap(t *testing.T) {
+	t.Parallel()
+
+	type Base struct {
+		ID   int
+		Kind string
+	}
+
+	type Server struct {
+		Host string
+		Port int
+	}
+
+	type Config struct {
+		Name    string
+		Base    `mapstructure:",squash"`
+		Zone    string
+		Servers []Server
+		Primary Server
+		Labels  map[string]string
+		Extra   map[string]any `mapstructure:",remain"`
+		Last    bool
+	}
+
+	input := Config{
+		Name:    "app",
+		Base:    Base{ID: 1, Kind: "web"},
+		Zone:    "eu",
+		Servers: []Server{{Host: "a", Port: 1}},
+		Primary: Server{Host: "b", Port: 2},
+		Labels:  map[string]string{"team": "core", "env": "prod"},
+		Extra:   map[string]any{"z": 1, "y": 2},
+		Last:    true,
+	}
+
+	var result OrderedMap
+	decoder, err := NewDecoder(&DecoderConfig{
+		RecursiveEncode: true,
+		Result:          &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	actual, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := `{"Name":"app","ID":1,"Kind":"web","Zone":"eu",` +
+		`"Servers":[{"Host":"a","Port":1}],"Primary":{"Host":"b","Port":2},` +
+		`"Labels":{"env":"prod","team":"core"},"y":2,"z":1,"Last":true}`
+	if string(actual) != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	primary, _ := result.Get("Primary")
+	if _, ok := primary.(*OrderedMap); !ok {
+		t.Fatalf("expected nested *OrderedMap, got %T", primary)
+	}
+}
+
+func TestDecode_OrderedMapFromMap(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"b": 1,
+		"a": 2,
+		"c": 3,
+	}
+
+	var result OrderedMap
+	if err := Decode(input, &result); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(result.Keys(), []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected keys: %v", result.Keys())
+	}
+}
+
+func TestDecode_OrderedMapInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	var result OrderedMap
+	if err := Decode("invalid", &result); err == nil {
+		t.Fatal("expected error")
+	}
+
+	var nilStruct *struct{ A int }
+	err := Decode(&nilStruct, &result)
+
+	var uerr *UnconvertibleTypeError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected UnconvertibleTypeError, got %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package mapstructure
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// ResolverFunc returns the value referenced by ref, the part of a
+// reference following its scheme and colon, such as "DB_PASSWORD" in
+// "env:DB_PASSWORD".
+type ResolverFunc func(ref string) (string, error)
+
+// Resolvers is a registry of ResolverFuncs keyed by scheme, used to
+// replace references such as "file:///run/secrets/db" or "env:DB_PASSWORD"
+// with the value they reference. Strings without the scheme of a
+// registered resolver are literals and are left unchanged. See
+// Resolvers.DecodeHook.
+//
+// The zero value is an empty registry ready to use. A Resolvers must not
+// be modified while a Decoder is using it.
+type Resolvers struct {
+	funcs map[string]ResolverFunc
+}
+
+// Register registers fn as the resolver for references with the given
+// scheme, replacing any resolver previously registered for it.
+//
+//	var r Resolvers
+//	r.Register("env", EnvResolver(nil))
+//	r.Register("file", FileResolver(FileResolverConfig{Dirs: []string{"/run/secrets"}}))
+func (r *Resolvers) Register(scheme string, fn ResolverFunc) {
+	if r.funcs == nil {
+		r.funcs = make(map[string]ResolverFunc)
+	}
+
+	r.funcs[scheme] = fn
+}
+
+// resolve resolves s if it is a reference with a registered scheme. It
+// reports whether s is such a reference.
+func (r *Resolvers) resolve(s string) (string, bool, error) {
+	if r == nil || r.funcs == nil {
+		return "", false, nil
+	}
+
+	scheme, ref, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", false, nil
+	}
+
+	fn, ok := r.funcs[scheme]
+	if !ok {
+		return "", false, nil
+	}
+
+	value, err := fn(ref)
+	if err != nil {
+		return "", true, fmt.Errorf("resolving %s reference: %w", scheme, err)
+	}
+
+	return value, true, nil
+}
+
+// DecodeHook returns a DecodeHookFunc replacing references in strings by
+// the value they reference, before they are decoded. Resolution errors
+// are reported with the path of the field holding the reference.
+func (r *Resolvers) DecodeHook() DecodeHookFunc {
+	return func(f reflect.Value, t reflect.Value) (any, error) {
+		if f.Kind() != reflect.String {
+			return f.Interface(), nil
+		}
+
+		value, ok, err := r.resolve(f.String())
+		if !ok || err != nil {
+			return f.Interface(), err
+		}
+
+		return reflect.ValueOf(value).Convert(f.Type()).Interface(), nil
+	}
+}
+
+// EnvResolver returns a ResolverFunc returning the value of the
+// environment variable named by the reference, as in "env:DB_PASSWORD".
+// Variables are looked up with lookup, which defaults to os.LookupEnv if
+// nil. Unset variables are an error.
+func EnvResolver(lookup func(string) (string, bool)) ResolverFunc {
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	return func(ref string) (string, error) {
+		value, ok := lookup(ref)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", ref)
+		}
+
+		return value, nil
+	}
+}
+
+// MapResolver returns a ResolverFunc returning the value for the
+// reference in values. It is mostly useful to stub other resolvers in
+// tests.
+func MapResolver(values map[string]string) ResolverFunc {
+	return func(ref string) (string, error) {
+		value, ok := values[ref]
+		if !ok {
+			return "", fmt.Errorf("%q not found", ref)
+		}
+
+		return value, nil
+	}
+}
+
+// FileResolverConfig configures the ResolverFunc returned by
+// FileResolver.
+type FileResolverConfig struct {
+	// Dirs restricts the files that can be referenced to those inside
+	// one of these directories, after resolving symbolic links. If empty,
+	// any file can be referenced.
+	Dirs []string
+
+	// MaxSize is the maximum size of a referenced file in bytes. If zero,
+	// there is no limit.
+	MaxSize int64
+}
+
+// FileResolver returns a ResolverFunc returning the contents of the file
+// referenced by an absolute path, as in "file:///run/secrets/db" or
+// "file:/run/secrets/db". The contents are returned unchanged, including
+// any trailing newline.
+func FileResolver(config FileResolverConfig) ResolverFunc {
+	return func(ref string) (string, error) {
+		path := ref
+		if strings.HasPrefix(path, "//") {
+			// Only local files, with an empty host, are supported.
+			path = path[2:]
+			if !strings.HasPrefix(path, "/") {
+				return "", errors.New("file reference must not have a host")
+			}
+		}
+
+		path = filepath.FromSlash(path)
+		if !filepath.IsAbs(path) {
+			return "", errors.New("file reference must be an absolute path")
+		}
+
+		if len(config.Dirs) > 0 {
+			// The resolved path is opened, so a symbolic link changed after
+			// the check cannot point outside of the directories.
+			var err error
+			if path, err = resolveFileInDirs(path, config.Dirs); err != nil {
+				return "", err
+			}
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+
+		var r io.Reader = file
+		if config.MaxSize > 0 {
+			info, err := file.Stat()
+			if err != nil {
+				return "", err
+			}
+			if info.Mode().IsRegular() && info.Size() > config.MaxSize {
+				return "", fmt.Errorf("file exceeds the maximum size of %d bytes", config.MaxSize)
+			}
+
+			// The file can still grow while it is read.
+			r = io.LimitReader(file, config.MaxSize+1)
+		}
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return "", err
+		}
+		if config.MaxSize > 0 && int64(len(data)) > config.MaxSize {
+			return "", fmt.Errorf("file exceeds the maximum size of %d bytes", config.MaxSize)
+		}
+
+		return string(data), nil
+	}
+}
+
+// resolveFileInDirs returns path with its symbolic links resolved, or an
+// error unless it is inside one of dirs.
+func resolveFileInDirs(path string, dirs []string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range dirs {
+		dir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(dir, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && rel != "." {
+			return resolved, nil
+		}
+	}
+
+	return "", errors.New("file is outside the allowed directories")
+}
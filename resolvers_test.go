@@ -0,0 +1,142 @@
+package mapstructure
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolvers(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "db")
+	if err := os.WriteFile(secret, []byte("s3cret"), 0o600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var resolvers Resolvers
+	resolvers.Register("env", EnvResolver(func(name string) (string, bool) {
+		if name == "DB_USER" {
+			return "admin", true
+		}
+
+		return "", false
+	}))
+	resolvers.Register("file", FileResolver(FileResolverConfig{Dirs: []string{dir}}))
+	resolvers.Register("vault", MapResolver(map[string]string{"db/token": "t0ken"}))
+
+	type Config struct {
+		User     string
+		Password string
+		Token    []byte
+		Literal  string
+		URL      string
+	}
+
+	input := map[string]any{
+		"user":     "env:DB_USER",
+		"password": "file://" + filepath.ToSlash(secret),
+		"token":    "vault:db/token",
+		"literal":  "plain value",
+		"url":      "https://example.com",
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		DecodeHook:       resolvers.DecodeHook(),
+		WeaklyTypedInput: true,
+		Result:           &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{
+		User:     "admin",
+		Password: "s3cret",
+		Token:    []byte("t0ken"),
+		Literal:  "plain value",
+		URL:      "https://example.com",
+	}
+	if result.User != expected.User || result.Password != expected.Password ||
+		string(result.Token) != string(expected.Token) || result.Literal != expected.Literal || result.URL != expected.URL {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+
+	err = decoder.Decode(map[string]any{"user": "env:MISSING"})
+	if err == nil || !strings.Contains(err.Error(), "'User' resolving env reference: environment variable MISSING is not set") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var derr *DecodeError
+	if !errors.As(err, &derr) || derr.Name() != "User" {
+		t.Fatalf("expected DecodeError for User, got %v", err)
+	}
+}
+
+func TestFileResolver(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	outside := t.TempDir()
+
+	small := filepath.Join(dir, "small")
+	large := filepath.Join(dir, "large")
+	other := filepath.Join(outside, "other")
+	link := filepath.Join(dir, "link")
+	inner := filepath.Join(dir, "inner")
+	for path, data := range map[string]string{small: "abc\n", large: strings.Repeat("x", 100), other: "other"} {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if err := os.Symlink(other, link); err != nil {
+		t.Skipf("symlinks not supported: %s", err)
+	}
+	if err := os.Symlink(small, inner); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resolve := FileResolver(FileResolverConfig{
+		Dirs:    []string{dir},
+		MaxSize: 10,
+	})
+
+	for _, ref := range []string{"//" + filepath.ToSlash(small), filepath.ToSlash(small), filepath.ToSlash(inner)} {
+		value, err := resolve(ref)
+		if err != nil || value != "abc\n" {
+			t.Errorf("%q: unexpected result %q, %v", ref, value, err)
+		}
+	}
+
+	cases := []struct {
+		ref string
+		err string
+	}{
+		{"//" + filepath.ToSlash(large), "file exceeds the maximum size of 10 bytes"},
+		{"//" + filepath.ToSlash(other), "file is outside the allowed directories"},
+		{"//" + filepath.ToSlash(link), "file is outside the allowed directories"},
+		{"//" + filepath.ToSlash(filepath.Join(dir, "..", filepath.Base(outside), "other")), "file is outside the allowed directories"},
+		{"relative/path", "file reference must be an absolute path"},
+		{"//host/path", "file reference must not have a host"},
+	}
+
+	for _, tc := range cases {
+		_, err := resolve(tc.ref)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%q: expected error %q, got %v", tc.ref, tc.err, err)
+		}
+	}
+
+	// Without restrictions, any file can be referenced.
+	value, err := FileResolver(FileResolverConfig{})(filepath.ToSlash(other))
+	if err != nil || value != "other" {
+		t.Errorf("unexpected result %q, %v", value, err)
+	}
+}
@@ -0,0 +1,104 @@
+package mapstructure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// parseStringMap parses s as a list of key-value pairs separated by
+// pairSep, with keys and values separated by kvSep. Keys and values may be
+// enclosed in double quotes to contain separators, and a backslash escapes
+// the character following it. Spaces around unquoted keys and values are
+// trimmed.
+func parseStringMap(s, pairSep, kvSep string) (map[string]string, error) {
+	if pairSep == "" || kvSep == "" {
+		return nil, errors.New("empty separator")
+	}
+
+	pairs, err := splitQuoted(s, pairSep, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		kv, err := splitQuoted(pair, kvSep, 2)
+		if err != nil {
+			return nil, err
+		}
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("missing %q between key and value", kvSep)
+		}
+
+		key := unquoteToken(kv[0])
+		if key == "" {
+			return nil, errors.New("empty key")
+		}
+
+		if _, ok := result[key]; ok {
+			return nil, newDecodeError("["+key+"]", errors.New("duplicate key"))
+		}
+
+		result[key] = unquoteToken(kv[1])
+	}
+
+	return result, nil
+}
+
+// splitQuoted splits s around sep, ignoring separators enclosed in double
+// quotes or escaped with a backslash, into at most n parts if n > 0. An
+// empty sep does not split s.
+func splitQuoted(s, sep string, n int) ([]string, error) {
+	if sep == "" {
+		return []string{s}, nil
+	}
+
+	var parts []string
+
+	start, inQuotes := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && strings.HasPrefix(s[i:], sep) && (n <= 0 || len(parts) < n-1):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i = start - 1
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("unterminated quoted string")
+	}
+
+	return append(parts, s[start:]), nil
+}
+
+// unquoteToken trims spaces around tok, removes its enclosing double
+// quotes, if any, and resolves backslash escapes.
+func unquoteToken(tok string) string {
+	tok = strings.TrimSpace(tok)
+
+	var b strings.Builder
+	for i := 0; i < len(tok); i++ {
+		switch tok[i] {
+		case '\\':
+			if i+1 < len(tok) {
+				i++
+				b.WriteByte(tok[i])
+			}
+		case '"':
+			// Quotes only delimit literal text.
+		default:
+			b.WriteByte(tok[i])
+		}
+	}
+
+	return b.String()
+}
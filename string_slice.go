@@ -0,0 +1,67 @@
+package mapstructure
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// splitFields splits s into fields separated by sep, the way a CSV record
+// is split: a field may be enclosed in double quotes to contain sep, a
+// doubled quote standing for a quote inside it. Spaces around fields are
+// trimmed, but kept inside quotes. Errors are reported with the index of
+// the malformed field. An empty sep does not split s.
+func splitFields(s, sep string) ([]string, error) {
+	var fields []string
+	for i := 0; ; i++ {
+		s = strings.TrimLeft(s, " \t")
+
+		var field string
+		if strings.HasPrefix(s, `"`) {
+			var err error
+			if field, s, err = splitQuotedField(s[1:], sep); err != nil {
+				return nil, newDecodeError("["+strconv.Itoa(i)+"]", err)
+			}
+		} else if end := strings.Index(s, sep); end >= 0 && sep != "" {
+			field, s = strings.TrimSpace(s[:end]), s[end:]
+		} else {
+			field, s = strings.TrimSpace(s), ""
+		}
+
+		fields = append(fields, field)
+		if s == "" {
+			return fields, nil
+		}
+		s = s[len(sep):]
+	}
+}
+
+// splitQuotedField returns the quoted field at the start of s, which
+// follows its opening quote, and the rest of s starting at the next
+// separator, if any.
+func splitQuotedField(s, sep string) (string, string, error) {
+	var b strings.Builder
+	for {
+		end := strings.IndexByte(s, '"')
+		if end < 0 {
+			return "", "", errors.New("unterminated quoted string")
+		}
+
+		b.WriteString(s[:end])
+		s = s[end+1:]
+
+		if !strings.HasPrefix(s, `"`) {
+			break
+		}
+
+		b.WriteByte('"')
+		s = s[1:]
+	}
+
+	s = strings.TrimLeft(s, " \t")
+	if s != "" && (sep == "" || !strings.HasPrefix(s, sep)) {
+		return "", "", errors.New("unexpected text after quoted string")
+	}
+
+	return b.String(), s, nil
+}
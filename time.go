@@ -0,0 +1,174 @@
+package mapstructure
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeHookConfig configures the DecodeHookFunc returned by TimeHookFunc.
+type TimeHookConfig struct {
+	// Layouts are the layouts tried in order to parse strings, as
+	// accepted by time.Parse.
+	Layouts []string
+
+	// Epoch is the unit of Unix timestamps, such as time.Second,
+	// time.Millisecond or time.Nanosecond. If set, integers, floats,
+	// json.Number and numeric strings matching none of the layouts are
+	// converted as a number of Epoch since the Unix epoch.
+	Epoch time.Duration
+
+	// Location is the location of times parsed with a layout without
+	// time zone information, and of Unix timestamps. It defaults to UTC.
+	Location *time.Location
+}
+
+// timeLayoutsError is returned when a string matches none of the layouts
+// of a TimeHookConfig. It wraps the error returned for each layout.
+type timeLayoutsError struct {
+	layouts []string
+	errs    []error
+}
+
+func (e *timeLayoutsError) Error() string {
+	quoted := make([]string, len(e.layouts))
+	for i, layout := range e.layouts {
+		quoted[i] = strconv.Quote(layout)
+	}
+
+	return "parsing time: does not match any of the layouts " + strings.Join(quoted, ", ")
+}
+
+func (e *timeLayoutsError) Unwrap() []error { return e.errs }
+
+func (*timeLayoutsError) singleFailure() {}
+
+// parseTime parses s with the layouts of c.
+func (c *TimeHookConfig) parseTime(s string) (time.Time, error) {
+	loc := c.location()
+
+	errs := make([]error, 0, len(c.Layouts))
+	for _, layout := range c.Layouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return t, nil
+		}
+
+		errs = append(errs, wrapTimeParseError(err))
+	}
+
+	if c.Epoch != 0 {
+		if t, ok, err := c.epochString(s); ok {
+			return t, err
+		}
+	}
+
+	if len(c.Layouts) == 0 {
+		return time.Time{}, errors.New("parsing time: not a Unix timestamp")
+	}
+
+	return time.Time{}, &timeLayoutsError{layouts: c.Layouts, errs: errs}
+}
+
+// epochString parses s as a Unix timestamp. It reports whether s is a
+// number at all.
+func (c *TimeHookConfig) epochString(s string) (time.Time, bool, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		t, err := c.epochInt(i)
+		return t, true, err
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, false, nil
+	}
+
+	t, err := c.epochFloat(f)
+
+	return t, true, err
+}
+
+func (c *TimeHookConfig) epochInt(i int64) (time.Time, error) {
+	var t time.Time
+	if c.Epoch >= time.Second {
+		mult := int64(c.Epoch / time.Second)
+		if i > math.MaxInt64/mult || i < math.MinInt64/mult {
+			return time.Time{}, errors.New("parsing time: Unix timestamp out of range")
+		}
+		t = time.Unix(i*mult, 0)
+	} else {
+		per := int64(time.Second / c.Epoch)
+		t = time.Unix(i/per, (i%per)*int64(c.Epoch))
+	}
+
+	return t.In(c.location()), nil
+}
+
+func (c *TimeHookConfig) epochFloat(f float64) (time.Time, error) {
+	secs := f * c.Epoch.Seconds()
+	if math.IsNaN(secs) || secs >= math.MaxInt64 || secs < math.MinInt64 {
+		return time.Time{}, errors.New("parsing time: Unix timestamp out of range")
+	}
+
+	sec, frac := math.Modf(secs)
+
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).In(c.location()), nil
+}
+
+func (c *TimeHookConfig) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+
+	return c.Location
+}
+
+// convertTime converts data to a time.Time. It reports whether data is of
+// a type that can be converted.
+func (c *TimeHookConfig) convertTime(data any) (time.Time, bool, error) {
+	if n, ok := data.(json.Number); ok {
+		if c.Epoch == 0 {
+			return time.Time{}, false, nil
+		}
+
+		t, ok, err := c.epochString(string(n))
+		if !ok {
+			err = errors.New("parsing time: invalid number")
+		}
+
+		return t, true, err
+	}
+
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.String {
+		t, err := c.parseTime(v.String())
+		return t, true, err
+	}
+
+	if c.Epoch == 0 {
+		return time.Time{}, false, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		t, err := c.epochInt(v.Int())
+		return t, true, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return time.Time{}, true, errors.New("parsing time: Unix timestamp out of range")
+		}
+
+		t, err := c.epochInt(int64(v.Uint()))
+
+		return t, true, err
+	case reflect.Float32, reflect.Float64:
+		t, err := c.epochFloat(v.Float())
+		return t, true, err
+	default:
+		return time.Time{}, false, nil
+	}
+}
@@ -0,0 +1,116 @@
+package mapstructure
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// TypeDecoderFunc decodes input into target, replacing the default
+// decoding for the target type. target is always settable. Sub-values
+// should be decoded through ctx so that decode hooks, error paths and
+// metadata behave as for any other value. See DecoderConfig.TypeDecoders.
+type TypeDecoderFunc func(ctx *DecodeContext, input any, target reflect.Value) error
+
+// DecodeContext gives a TypeDecoderFunc access to the Decoder that called
+// it, positioned at the value being decoded.
+type DecodeContext struct {
+	d    *Decoder
+	name string
+}
+
+// Name returns the path of the value being decoded, as used in errors
+// and metadata. It is empty for the root value.
+func (c *DecodeContext) Name() string {
+	return c.name
+}
+
+// Decode decodes input into target at the current path, bypassing the
+// TypeDecoderFunc registered for the type of target. This is useful to
+// extend the default decoding rather than replace it.
+func (c *DecodeContext) Decode(input any, target reflect.Value) error {
+	return c.d.decodeDefault(c.name, input, target)
+}
+
+// DecodeField decodes input into target with the path of the struct
+// field or map key named field below the current path.
+func (c *DecodeContext) DecodeField(field string, input any, target reflect.Value) error {
+	name := field
+	if c.name != "" {
+		name = c.name + "." + field
+	}
+
+	return c.d.decode(name, input, target)
+}
+
+// DecodeIndex decodes input into target with the path of the element at
+// index i below the current path.
+func (c *DecodeContext) DecodeIndex(i int, input any, target reflect.Value) error {
+	return c.d.decode(c.name+"["+strconv.Itoa(i)+"]", input, target)
+}
+
+// DecodeKey decodes input into target with the path of the map entry
+// with the given key below the current path.
+func (c *DecodeContext) DecodeKey(key any, input any, target reflect.Value) error {
+	return c.d.decode(c.name+"["+fmt.Sprint(key)+"]", input, target)
+}
+
+// typeDecoder returns the TypeDecoderFunc registered for t, either
+// directly or for an interface implemented by t or *t.
+func (d *Decoder) typeDecoder(t reflect.Type) (TypeDecoderFunc, bool) {
+	if len(d.config.TypeDecoders) == 0 {
+		return nil, false
+	}
+
+	if fn, ok := d.config.TypeDecoders[t]; ok {
+		return fn, true
+	}
+
+	if fn, ok := d.typeDecoderCache[t]; ok {
+		return fn, fn != nil
+	}
+
+	var found TypeDecoderFunc
+	pt := reflect.PtrTo(t)
+	for _, key := range d.typeDecoderInterfaces {
+		if t.Implements(key) || pt.Implements(key) {
+			found = d.config.TypeDecoders[key]
+			break
+		}
+	}
+
+	if d.typeDecoderCache == nil {
+		d.typeDecoderCache = make(map[reflect.Type]TypeDecoderFunc)
+	}
+	d.typeDecoderCache[t] = found
+
+	return found, found != nil
+}
+
+// typeDecoderInterfaces returns the interface types among the keys of
+// decoders in the order they are matched: the interfaces with the most
+// methods, which are the most specific, come first, and the others are
+// sorted by name so that the match does not depend on the map order.
+func typeDecoderInterfaces(decoders map[reflect.Type]TypeDecoderFunc) []reflect.Type {
+	var ifaces []reflect.Type
+	for key := range decoders {
+		if key.Kind() == reflect.Interface {
+			ifaces = append(ifaces, key)
+		}
+	}
+
+	sort.Slice(ifaces, func(i, j int) bool {
+		a, b := ifaces[i], ifaces[j]
+		if a.NumMethod() != b.NumMethod() {
+			return a.NumMethod() > b.NumMethod()
+		}
+		if a.String() != b.String() {
+			return a.String() < b.String()
+		}
+
+		return a.PkgPath() < b.PkgPath()
+	})
+
+	return ifaces
+}
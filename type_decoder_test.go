@@ -0,0 +1,259 @@
+package mapstructure
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// testSet is a container type decoded from a list of elements.
+type testSet struct {
+	items map[string]struct{}
+}
+
+func (s testSet) Sorted() []string {
+	out := make([]string, 0, len(s.items))
+	for k := range s.items {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+func decodeTestSet(ctx *DecodeContext, input any, target reflect.Value) error {
+	list, ok := input.([]any)
+	if !ok {
+		return errors.New("expected a list")
+	}
+
+	set := testSet{items: make(map[string]struct{}, len(list))}
+	for i, item := range list {
+		var s string
+		if err := ctx.DecodeIndex(i, item, reflect.ValueOf(&s).Elem()); err != nil {
+			return err
+		}
+		set.items[s] = struct{}{}
+	}
+
+	target.Set(reflect.ValueOf(set))
+
+	return nil
+}
+
+func TestDecoder_TypeDecoders(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Tags  testSet
+		Other testSet
+	}
+
+	input := map[string]any{
+		"tags": []any{"b", "a", "b"},
+	}
+
+	var result Config
+	var md Metadata
+	decoder, err := NewDecoder(&DecoderConfig{
+		TypeDecoders: map[reflect.Type]TypeDecoderFunc{
+			reflect.TypeOf(testSet{}): decodeTestSet,
+		},
+		Metadata: &md,
+		Result:   &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(result.Tags.Sorted(), []string{"a", "b"}) {
+		t.Fatalf("unexpected result: %v", result.Tags.Sorted())
+	}
+
+	sort.Strings(md.Keys)
+	expectedKeys := []string{"Tags", "Tags[0]", "Tags[1]", "Tags[2]"}
+	if !reflect.DeepEqual(md.Keys, expectedKeys) {
+		t.Fatalf("expected keys %v, got %v", expectedKeys, md.Keys)
+	}
+}
+
+func TestDecoder_TypeDecodersErrors(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Tags testSet
+	}
+
+	decode := func(input map[string]any) error {
+		var result Config
+		decoder, err := NewDecoder(&DecoderConfig{
+			TypeDecoders: map[reflect.Type]TypeDecoderFunc{
+				reflect.TypeOf(testSet{}): decodeTestSet,
+			},
+			Result: &result,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		return decoder.Decode(input)
+	}
+
+	// Errors of the TypeDecoderFunc are wrapped with the field path.
+	err := decode(map[string]any{"tags": "a"})
+	if err == nil || !strings.Contains(err.Error(), "'Tags' expected a list") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Errors of recursive decoding keep the path of the sub-value.
+	err = decode(map[string]any{"tags": []any{"a", 1}})
+	if err == nil || !strings.Contains(err.Error(), "'Tags[1]' expected type 'string', got unconvertible type 'int'") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type testUpperer interface {
+	Upper()
+}
+
+type testUpper string
+
+func (*testUpper) Upper() {}
+
+func TestDecoder_TypeDecodersInterface(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Name  testUpper
+		Names []testUpper
+	}
+
+	input := map[string]any{
+		"name":  "a",
+		"names": []any{"b", "c"},
+	}
+
+	var result Config
+	decoder, err := NewDecoder(&DecoderConfig{
+		TypeDecoders: map[reflect.Type]TypeDecoderFunc{
+			reflect.TypeOf((*testUpperer)(nil)).Elem(): func(ctx *DecodeContext, input any, target reflect.Value) error {
+				// Extend the default decoding.
+				if err := ctx.Decode(input, target); err != nil {
+					return err
+				}
+				target.SetString(strings.ToUpper(target.String()))
+
+				return nil
+			},
+		},
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := Config{Name: "A", Names: []testUpper{"B", "C"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, result)
+	}
+}
+
+type testCased string
+
+func (*testCased) Upper() {}
+
+func (*testCased) Lower() {}
+
+func TestDecoder_TypeDecodersInterfaceOrder(t *testing.T) {
+	t.Parallel()
+
+	setTo := func(s string) TypeDecoderFunc {
+		return func(_ *DecodeContext, _ any, target reflect.Value) error {
+			target.SetString(s)
+			return nil
+		}
+	}
+
+	upperer := reflect.TypeOf((*testUpperer)(nil)).Elem()
+	lowerer := reflect.TypeOf((*interface{ Lower() })(nil)).Elem()
+	both := reflect.TypeOf((*interface {
+		Lower()
+		Upper()
+	})(nil)).Elem()
+
+	cases := []struct {
+		decoders map[reflect.Type]TypeDecoderFunc
+		expected testCased
+	}{
+		// The interface with the most methods is the most specific.
+		{map[reflect.Type]TypeDecoderFunc{upperer: setTo("upper"), lowerer: setTo("lower"), both: setTo("both")}, "both"},
+		// Otherwise the first one by name is used.
+		{map[reflect.Type]TypeDecoderFunc{upperer: setTo("upper"), lowerer: setTo("lower")}, "lower"},
+	}
+
+	for _, tc := range cases {
+		// Map iteration order varies, so decode several times.
+		for i := 0; i < 20; i++ {
+			var result testCased
+			decoder, err := NewDecoder(&DecoderConfig{
+				TypeDecoders: tc.decoders,
+				Result:       &result,
+			})
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+
+			if err := decoder.Decode("x"); err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if result != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, result)
+			}
+		}
+	}
+}
+
+func TestDecodeContext_DecodeField(t *testing.T) {
+	t.Parallel()
+
+	type Inner struct {
+		Value int
+	}
+
+	type Outer struct {
+		Inner Inner
+	}
+
+	input := map[string]any{
+		"inner": map[string]any{"v": "bad"},
+	}
+
+	var result Outer
+	decoder, err := NewDecoder(&DecoderConfig{
+		TypeDecoders: map[reflect.Type]TypeDecoderFunc{
+			reflect.TypeOf(Inner{}): func(ctx *DecodeContext, input any, target reflect.Value) error {
+				m := input.(map[string]any)
+				return ctx.DecodeField("v", m["v"], target.Field(0))
+			},
+		},
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = decoder.Decode(input)
+	if err == nil || !strings.Contains(err.Error(), "'Inner.v' ") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}